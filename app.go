@@ -2,6 +2,7 @@ package hadoop_streaming
 
 import (
 	"fmt"
+	"os"
 )
 
 const (
@@ -47,3 +48,11 @@ func (app *Application) Run(mode string) error {
 	}
 	return fmt.Errorf("unknown mode: %s", mode)
 }
+
+func (app *Application) Main(mode string) int {
+	err := app.Run(mode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+	return ExitCode(err)
+}
@@ -0,0 +1,120 @@
+package hadoop_streaming
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type DistributedCache struct {
+	jobConf *JobConf
+	workDir string
+	aliases map[string]string
+}
+
+func NewDistributedCache(jobConf *JobConf) *DistributedCache {
+	return &DistributedCache{
+		jobConf: jobConf,
+		workDir: ".",
+		aliases: make(map[string]string),
+	}
+}
+
+func (cache *DistributedCache) WithWorkDir(workDir string) *DistributedCache {
+	cache.workDir = workDir
+	return cache
+}
+
+func (cache *DistributedCache) WithAlias(alias, target string) *DistributedCache {
+	cache.aliases[alias] = target
+	return cache
+}
+
+func (cache *DistributedCache) GetFile(alias string) (string, error) {
+	file, err := cache.resolve(alias, "files")
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(file); err != nil {
+		return "", fmt.Errorf("cache file %q: %w", alias, err)
+	} else if info.IsDir() {
+		return "", fmt.Errorf("cache file %q is a directory: %v", alias, file)
+	}
+	return file, nil
+}
+
+func (cache *DistributedCache) GetArchive(alias string) (string, error) {
+	dir, err := cache.resolve(alias, "archives")
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("cache archive %q: %w", alias, err)
+	} else if !info.IsDir() {
+		return "", fmt.Errorf("cache archive %q is not unpacked: %v", alias, dir)
+	}
+	return dir, nil
+}
+
+func (cache *DistributedCache) resolve(alias string, kind string) (string, error) {
+	if alias == "" || strings.ContainsRune(alias, '/') {
+		return "", fmt.Errorf("invalid cache alias: %q", alias)
+	}
+	if target, ok := cache.aliases[alias]; ok {
+		return filepath.Abs(target)
+	}
+	link := filepath.Join(cache.workDir, alias)
+	if _, err := os.Lstat(link); err == nil {
+		return filepath.Abs(link)
+	}
+	entries := cache.jobConf.GetStrings("mapreduce.job.cache."+kind, nil)
+	for _, entry := range entries {
+		u, err := url.Parse(strings.TrimSpace(entry))
+		if err != nil {
+			continue
+		}
+		name := u.Fragment
+		if name == "" {
+			name = path.Base(u.Path)
+		}
+		if name != alias {
+			continue
+		}
+		if u.Scheme == "" || u.Scheme == "file" {
+			return filepath.Abs(filepath.FromSlash(u.Path))
+		}
+		return "", fmt.Errorf("cache entry %q is not localized: %v not found", alias, link)
+	}
+	for _, local := range cache.jobConf.GetStrings("mapreduce.job.cache.local."+kind, nil) {
+		if filepath.Base(local) == alias {
+			return filepath.Abs(local)
+		}
+	}
+	return "", fmt.Errorf("cache entry %q not found in %v, shipped entries: %v", alias, cache.workDir, entries)
+}
+
+func StageCacheAlias(workDir, alias, target string) error {
+	if alias == "" || strings.ContainsRune(alias, '/') {
+		return fmt.Errorf("invalid cache alias: %q", alias)
+	}
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(target); err != nil {
+		return err
+	}
+	link := filepath.Join(workDir, alias)
+	if current, err := os.Readlink(link); err == nil {
+		if current == target {
+			return nil
+		}
+		if err := os.Remove(link); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(target, link)
+}
@@ -0,0 +1,284 @@
+package hadoop_streaming
+
+import (
+	"bytes"
+	"strings"
+)
+
+type Comparator = func(a, b []byte) int
+
+type KeyFieldComparator struct {
+	fields         *KeyFields
+	numKeyFields   int
+	fieldSeparator []byte
+}
+
+func NewKeyFieldComparator(options string) (*KeyFieldComparator, error) {
+	fields, err := ParseKeyFields(options)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyFieldComparator{
+		fields:         fields,
+		numKeyFields:   1,
+		fieldSeparator: []byte{'\t'},
+	}, nil
+}
+
+func NewComparatorFromJobConf(conf *JobConf) (*KeyFieldComparator, error) {
+	options := ""
+	class := conf.getStringAlias("", "mapreduce.job.output.key.comparator.class", "mapred.output.key.comparator.class")
+	if strings.HasSuffix(class, ".KeyFieldBasedComparator") {
+		options = conf.getStringAlias("", "mapreduce.partition.keycomparator.options", "mapred.text.key.comparator.options")
+	}
+	comparator, err := NewKeyFieldComparator(options)
+	if err != nil {
+		return nil, err
+	}
+	return comparator.
+		WithSeparator(conf.getStringAlias("\t", "mapreduce.map.output.key.field.separator", "map.output.key.field.separator")).
+		WithLineKey(conf.GetInt("stream.num.map.output.key.fields", 1),
+			conf.GetString("stream.map.output.field.separator", "\t")), nil
+}
+
+func (comparator *KeyFieldComparator) WithSeparator(separator string) *KeyFieldComparator {
+	comparator.fields.WithSeparator(separator)
+	return comparator
+}
+
+func (comparator *KeyFieldComparator) WithLineKey(numKeyFields int, fieldSeparator string) *KeyFieldComparator {
+	comparator.numKeyFields = numKeyFields
+	comparator.fieldSeparator = []byte(fieldSeparator)
+	return comparator
+}
+
+func LineKey(line []byte, fieldSeparator []byte, numKeyFields int) []byte {
+	end := 0
+	for n := 0; n < numKeyFields; n++ {
+		pos := bytes.Index(line[end:], fieldSeparator)
+		if pos < 0 {
+			return line
+		}
+		if n == numKeyFields-1 {
+			return line[:end+pos]
+		}
+		end += pos + len(fieldSeparator)
+	}
+	return line
+}
+
+func (comparator *KeyFieldComparator) CompareLines(a, b []byte) int {
+	return comparator.Compare(LineKey(a, comparator.fieldSeparator, comparator.numKeyFields),
+		LineKey(b, comparator.fieldSeparator, comparator.numKeyFields))
+}
+
+func (comparator *KeyFieldComparator) Compare(a, b []byte) int {
+	fields := comparator.fields
+	specs := fields.Specs()
+	if len(specs) == 0 {
+		return bytes.Compare(a, b)
+	}
+	lengthsA := fields.wordLengths(a)
+	lengthsB := fields.wordLengths(b)
+	for _, spec := range specs {
+		startA := fields.startOffset(a, lengthsA, spec)
+		endA := fields.endOffset(a, lengthsA, spec)
+		startB := fields.startOffset(b, lengthsB, spec)
+		endB := fields.endOffset(b, lengthsB, spec)
+		if result := compareByteSequence(a, startA, endA, b, startB, endB, spec); result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+func compareByteSequence(a []byte, start1, end1 int, b []byte, start2, end2 int, spec KeySpec) int {
+	if start1 == -1 {
+		if spec.Reverse {
+			return 1
+		}
+		return -1
+	}
+	if start2 == -1 {
+		if spec.Reverse {
+			return -1
+		}
+		return 1
+	}
+	var result int
+	if spec.Numeric {
+		result = numericalCompare(a, start1, end1, b, start2, end2)
+	} else {
+		result = bytes.Compare(a[start1:max(start1, end1+1)], b[start2:max(start2, end2+1)])
+	}
+	if spec.Reverse {
+		return -result
+	}
+	return result
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func byteAt(b []byte, i, end int) byte {
+	if i > end || i >= len(b) {
+		return 0
+	}
+	return b[i]
+}
+
+func numericalCompare(a []byte, start1, end1 int, b []byte, start2, end2 int) int {
+	i, j := start1, start2
+	mul := 1
+	firstA := byteAt(a, i, end1)
+	firstB := byteAt(b, j, end2)
+	if firstA == '-' {
+		if firstB != '-' {
+			return oneNegativeCompare(a, start1+1, end1, b, start2, end2)
+		}
+		i++
+	}
+	if firstB == '-' {
+		if firstA != '-' {
+			return -oneNegativeCompare(b, start2+1, end2, a, start1, end1)
+		}
+		j++
+	}
+	if firstA == '-' && firstB == '-' {
+		mul = -1
+	}
+	for i <= end1 && a[i] == '0' {
+		i++
+	}
+	for j <= end2 && b[j] == '0' {
+		j++
+	}
+	for i <= end1 && j <= end2 {
+		if !isDigit(a[i]) || a[i] != b[j] {
+			break
+		}
+		i++
+		j++
+	}
+	if i <= end1 {
+		firstA = a[i]
+	}
+	if j <= end2 {
+		firstB = b[j]
+	}
+	firstResult := int(int8(firstA)) - int(int8(firstB))
+	if (firstA == '.' && (!isDigit(firstB) || j > end2)) ||
+		(firstB == '.' && (!isDigit(firstA) || i > end1)) {
+		return mul * decimalCompare(a, i, end1, b, j, end2)
+	}
+	remainA := 0
+	for i <= end1 {
+		c := a[i]
+		i++
+		if !isDigit(c) {
+			break
+		}
+		remainA++
+	}
+	remainB := 0
+	for j <= end2 {
+		c := b[j]
+		j++
+		if !isDigit(c) {
+			break
+		}
+		remainB++
+	}
+	if remainA == remainB {
+		return mul * firstResult
+	}
+	return mul * (remainA - remainB)
+}
+
+func decimalCompare(a []byte, i, end1 int, b []byte, j, end2 int) int {
+	if i > end1 {
+		return -decimalCompare1(b, j+1, end2)
+	}
+	if j > end2 {
+		return decimalCompare1(a, i+1, end1)
+	}
+	if a[i] == '.' && b[j] == '.' {
+		for i <= end1 && j <= end2 {
+			if a[i] != b[j] {
+				if isDigit(a[i]) && isDigit(b[j]) {
+					return int(a[i]) - int(b[j])
+				}
+				if isDigit(a[i]) {
+					return 1
+				}
+				if isDigit(b[j]) {
+					return -1
+				}
+				return 0
+			}
+			i++
+			j++
+		}
+		if i > end1 && j > end2 {
+			return 0
+		}
+		if i > end1 {
+			return -decimalCompare1(b, j, end2)
+		}
+		if j > end2 {
+			return decimalCompare1(a, i, end1)
+		}
+	} else if a[i] == '.' {
+		return decimalCompare1(a, i+1, end1)
+	} else if b[j] == '.' {
+		return -decimalCompare1(b, j+1, end2)
+	}
+	return 0
+}
+
+func decimalCompare1(a []byte, i, end int) int {
+	for ; i <= end; i++ {
+		if a[i] == '0' {
+			continue
+		}
+		if isDigit(a[i]) {
+			return 1
+		}
+		return 0
+	}
+	return 0
+}
+
+func oneNegativeCompare(a []byte, start1, end1 int, b []byte, start2, end2 int) int {
+	if !isZero(a, start1, end1) {
+		return -1
+	}
+	if !isZero(b, start2, end2) {
+		return -1
+	}
+	return 0
+}
+
+func isZero(a []byte, start, end int) bool {
+	i := start
+	for ; i <= end; i++ {
+		if a[i] != '0' {
+			if a[i] != '.' && isDigit(a[i]) {
+				return false
+			}
+			break
+		}
+	}
+	if i <= end && a[i] == '.' {
+		for i++; i <= end; i++ {
+			if a[i] != '0' {
+				if isDigit(a[i]) {
+					return false
+				}
+				break
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package hadoop_streaming
+
+import "testing"
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func TestKeyFieldComparator(t *testing.T) {
+	tests := []struct {
+		options   string
+		separator string
+		a, b      string
+		want      int
+	}{
+		{"", "", "b", "a", 1},
+		{"-k1,1", "", "a\tz", "a\ty", 0},
+		{"-k2,2", "", "b\t1", "a\t2", -1},
+		{"-k2,2r", "", "b\t1", "a\t2", 1},
+		{"-k1,1n", "", "10", "9", 1},
+		{"-k1,1n", "", "-5", "3", -1},
+		{"-k1,1n", "", "-1", "-10", 1},
+		// Hadoop compares the stale first bytes when the digits match to the end.
+		{"-k1,1n", "", "0010", "10", -1},
+		{"-k1,1n", "", "007", "7", -1},
+		{"-k1,1n", "", "-0.0", "0", 0},
+		{"-k1,1n", "", "0", "-0", 0},
+		{"-k1,1n", "", "1.50", "1.5", 0},
+		{"-k1,1n", "", "1.05", "1.5", -1},
+		{"-k1,1n", "", ".5", "0.50", 0},
+		{"-k1,1n", "", "abc", "1", -1},
+		{"-k2,2nr", "", "x\t10", "x\t9", -1},
+		{"-k2,2nr", "", "x\t-1", "x\t-2", -1},
+		{"-k1,1 -k2,2nr", "", "a\t1", "a\t2", 1},
+		{"-k1,1 -k2,2nr", "", "a\t1", "b\t2", -1},
+		{"-nr -k2,2", "", "x\t10", "x\t9", -1},
+		{"-k1.3,1.5", "", "zzabc", "aaabd", -1},
+		{"-k1.3,1.5", "", "xxcde\t1", "yycde\t2", 0},
+		{"-k1.3,1.5", "", "abc\tx", "abc\ty", -1},
+		{"-k1.3", "", "xxb\t1", "yyb\t2", -1},
+		{"-k1.3,1.5", "", "ab", "abc", -1},
+		{"-k2,2", "", "a", "a\tb", -1},
+		{"-k2,2", "", "a\tb", "a", 1},
+		{"-k2,2", "", "a", "b", -1},
+		{"-k2,2r", "", "a", "a\tb", 1},
+		{"-k2,2n", "::", "a::2", "b::10", -1},
+		{"-k2,2", "::", "b::x:y", "a::x:z", -1},
+		{"-k3,3", "::", "a::b::c", "a::b", 1},
+		{"-k2,2", "é", "aéb", "béa", 1},
+	}
+	for _, test := range tests {
+		comparator, err := NewKeyFieldComparator(test.options)
+		if err != nil {
+			t.Fatalf("NewKeyFieldComparator(%q): %v", test.options, err)
+		}
+		if test.separator != "" {
+			comparator.WithSeparator(test.separator)
+		}
+		if got := sign(comparator.Compare([]byte(test.a), []byte(test.b))); got != test.want {
+			t.Errorf("%q sep %q: Compare(%q, %q) = %v, want %v",
+				test.options, test.separator, test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestParseKeyFields(t *testing.T) {
+	tests := []struct {
+		options string
+		specs   []KeySpec
+	}{
+		{"-k2,2nr", []KeySpec{{BeginField: 2, BeginChar: 1, EndField: 2, EndChar: 0, Numeric: true, Reverse: true}}},
+		{"-k1.3,1.5", []KeySpec{{BeginField: 1, BeginChar: 3, EndField: 1, EndChar: 5}}},
+		{"-k 2", []KeySpec{{BeginField: 2, BeginChar: 1}}},
+		{"-n -k1,1 -k2,2r", []KeySpec{
+			{BeginField: 1, BeginChar: 1, EndField: 1, Numeric: true},
+			{BeginField: 2, BeginChar: 1, EndField: 2, Reverse: true},
+		}},
+	}
+	for _, test := range tests {
+		fields, err := ParseKeyFields(test.options)
+		if err != nil {
+			t.Fatalf("ParseKeyFields(%q): %v", test.options, err)
+		}
+		specs := fields.Specs()
+		if len(specs) != len(test.specs) {
+			t.Fatalf("ParseKeyFields(%q) = %+v, want %+v", test.options, specs, test.specs)
+		}
+		for i := range specs {
+			if specs[i] != test.specs[i] {
+				t.Errorf("ParseKeyFields(%q)[%v] = %+v, want %+v", test.options, i, specs[i], test.specs[i])
+			}
+		}
+	}
+}
+
+func TestCompareLines(t *testing.T) {
+	comparator, err := NewKeyFieldComparator("-k2,2n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comparator.WithSeparator(".").WithLineKey(1, "\t")
+	if got := sign(comparator.CompareLines([]byte("a.10\tz"), []byte("a.9\ta"))); got != 1 {
+		t.Errorf("CompareLines = %v, want 1", got)
+	}
+}
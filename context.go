@@ -3,12 +3,25 @@ package hadoop_streaming
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// DEFAULT_GRACE_PERIOD stays below YARN's default 250ms delay between
+// SIGTERM and SIGKILL (yarn.nodemanager.sleep-delay-before-sigkill.ms).
+const DEFAULT_GRACE_PERIOD = 200 * time.Millisecond
+
+const TASK_TIMEOUT_FRACTION = 0.8
+
+var ErrStopped = errors.New("task stopped")
+
 type Counter interface {
 	Increment(amount int)
 }
@@ -26,13 +39,16 @@ func NewDefaultCounter(group, counter string) *DefaultCounter {
 }
 
 func (counter *DefaultCounter) Increment(amount int) {
-	fmt.Fprintf(os.Stderr, "reporter:counter:%v,%v,%v\n", counter.group, counter.counter, amount)
+	fmt.Fprintf(os.Stderr, "reporter:counter:%v,%v,%v\n",
+		SanitizeCounterName(counter.group, COUNTER_GROUP_NAME_MAX),
+		SanitizeCounterName(counter.counter, COUNTER_NAME_MAX), amount)
 }
 
-type Context[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any] struct {
+type Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] struct {
 	reader             *bufio.Reader
 	readEnd            bool
 	writer             *bufio.Writer
+	writerMutex        sync.Mutex
 	noKeyIn            bool
 	noKeyOut           bool
 	keyInSerializer    Serializer[KEYIN]
@@ -40,13 +56,39 @@ type Context[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any] struct {
 	keyOutSerializer   Serializer[KEYOUT]
 	valueOutSerializer Serializer[VALUEOUT]
 	key                KEYIN
+	raw                []byte
+	recoverPanic       bool
+	writeErrorHandler  WriteErrorHandler[KEYOUT, VALUEOUT]
+	saltKey            func(key []byte) ([]byte, error)
+	stopped            atomic.Bool
+	gracePeriod        time.Duration
+	taskCtx            context.Context
+	cancelTask         context.CancelCauseFunc
+	recordDeadline     time.Time
+	recordCtx          context.Context
+	cancelRecord       context.CancelFunc
+	taskTimeout        time.Duration
+	jobConf            *JobConf
+	cache              *DistributedCache
+	outputs            *MultipleOutputs
+	counters           *Counters
+	stats              *frameworkStats
+	heartbeatInterval  time.Duration
+	heartbeat          *heartbeat
+	startTime          time.Time
 }
 
-func NewContext[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any](
+func NewContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
 	r io.Reader, w io.Writer) *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
 	var keyIn KEYIN
 	var keyOut KEYOUT
 	var noneKey NoneKey
+	taskCtx, cancelTask := context.WithCancelCause(context.Background())
+	stats := &frameworkStats{}
+	jobConf := NewJobConf()
+	counters := NewCounters().
+		WithMaxCounters(jobConf.GetInt("mapreduce.job.counters.max", DEFAULT_MAX_COUNTERS)).
+		AddCollector(stats.collect)
 	return &Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]{
 		reader:             bufio.NewReader(r),
 		writer:             bufio.NewWriter(w),
@@ -57,6 +99,14 @@ func NewContext[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any](
 		keyOutSerializer:   NewSerializer[KEYOUT](),
 		valueOutSerializer: NewSerializer[VALUEOUT](),
 		key:                keyIn,
+		gracePeriod:        DEFAULT_GRACE_PERIOD,
+		taskCtx:            taskCtx,
+		cancelTask:         cancelTask,
+		taskTimeout:        -1,
+		jobConf:            jobConf,
+		counters:           counters,
+		stats:              stats,
+		startTime:          time.Now(),
 	}
 }
 
@@ -84,6 +134,163 @@ func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithValueOutSerializer(
 	return ctx
 }
 
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithWriteErrorHandler(
+	handler WriteErrorHandler[KEYOUT, VALUEOUT]) *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.writeErrorHandler = handler
+	return ctx
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithRecoverPanic(
+	recoverPanic bool) *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.recoverPanic = recoverPanic
+	return ctx
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithGracePeriod(
+	gracePeriod time.Duration) *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.gracePeriod = gracePeriod
+	return ctx
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithTaskTimeout(
+	taskTimeout time.Duration) *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.taskTimeout = taskTimeout
+	return ctx
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithJobConf(
+	jobConf *JobConf) *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.jobConf = jobConf
+	return ctx
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetJobConf() *JobConf {
+	return ctx.jobConf
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithDistributedCache(
+	cache *DistributedCache) *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.cache = cache
+	return ctx
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetDistributedCache() *DistributedCache {
+	if ctx.cache == nil {
+		ctx.cache = NewDistributedCache(ctx.jobConf)
+	}
+	return ctx.cache
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetCacheFile(alias string) (string, error) {
+	return ctx.GetDistributedCache().GetFile(alias)
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetCacheArchive(alias string) (string, error) {
+	return ctx.GetDistributedCache().GetArchive(alias)
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithMultipleOutputs(
+	outputs *MultipleOutputs) *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.outputs = outputs
+	return ctx
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetMultipleOutputs() *MultipleOutputs {
+	if ctx.outputs == nil {
+		ctx.outputs = NewMultipleOutputs(ctx.jobConf)
+	}
+	return ctx.outputs
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithCounters(
+	counters *Counters) *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.counters = counters.AddCollector(ctx.stats.collect)
+	return ctx
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetCounters() *Counters {
+	return ctx.counters
+}
+
+// WithHeartbeat reports a status line with the record count, rate, current
+// key and, when mapreduce.map.input.length is known, progress and ETA every interval.
+// It is off by default, and progress is never reported without it. While it
+// is on the task is kept alive, so no per-record deadline is derived.
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithHeartbeat(
+	interval time.Duration) *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.heartbeatInterval = interval
+	return ctx
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) startHeartbeat() {
+	if ctx.heartbeatInterval <= 0 || ctx.heartbeat != nil {
+		return
+	}
+	ctx.heartbeat = startHeartbeat(ctx.heartbeatInterval, ctx.counters.Reporter(),
+		ctx.counters, ctx.stats, ctx.GetProgress)
+}
+
+// GetProgress estimates progress from the bytes read so far. Only heartbeats
+// report it to the framework, see WithHeartbeat.
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetProgress() Progress {
+	return NewProgress(ctx.stats.bytesRead.Load(), ctx.jobConf.InputLength(), time.Since(ctx.startTime))
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) stopHeartbeat() {
+	if ctx.heartbeat != nil {
+		ctx.heartbeat.Stop()
+	}
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetPartition(
+	partitioner Partitioner, key KEYOUT) (int, error) {
+	return PartitionKey(partitioner, ctx.keyOutSerializer, key, ctx.jobConf.NumReduces())
+}
+
+// GetTaskTimeout returns the per-record deadline. Unless set explicitly it is
+// a fraction of mapreduce.task.timeout, and disabled while heartbeats keep
+// the task alive.
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetTaskTimeout() time.Duration {
+	if ctx.taskTimeout >= 0 {
+		return ctx.taskTimeout
+	}
+	if ctx.heartbeatInterval > 0 {
+		return 0
+	}
+	return time.Duration(float64(ctx.jobConf.TaskTimeout()) * TASK_TIMEOUT_FRACTION)
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Stop() {
+	ctx.stopped.Store(true)
+	ctx.cancelTask(ErrStopped)
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Fail(err error) {
+	ctx.cancelTask(err)
+}
+
+// interruptReads makes a read blocked on the input return ErrStopped once the
+// task context is cancelled, so that Cleanup still runs after SIGTERM.
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) interruptReads() {
+	ctx.reader = bufio.NewReader(newInterruptibleReader(ctx.reader, ctx.taskCtx.Done()))
+}
+
+// GetContext returns the task context, or during Map and Reduce a context that
+// also expires at the record deadline. It is only built when asked for.
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetContext() context.Context {
+	if ctx.recordCtx == nil && !ctx.recordDeadline.IsZero() {
+		ctx.recordCtx, ctx.cancelRecord = context.WithDeadline(ctx.taskCtx, ctx.recordDeadline)
+	}
+	if ctx.recordCtx != nil {
+		return ctx.recordCtx
+	}
+	return ctx.taskCtx
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Stopped() bool {
+	return ctx.stopped.Load()
+}
+
 func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetCurrentKey() KEYIN {
 	return ctx.key
 }
@@ -94,6 +301,7 @@ func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) readline() ([]byte, error)
 	}
 	data, err := ctx.reader.ReadBytes('\n')
 	dataLen := len(data)
+	ctx.stats.bytesRead.Add(int64(dataLen))
 	if dataLen != 0 && data[dataLen-1] == '\n' {
 		data = data[:dataLen-1]
 		dataLen = len(data)
@@ -116,17 +324,30 @@ func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) readline() ([]byte, error)
 func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) readKeyValue() (KEYIN, VALUEIN, error) {
 	var err error
 	var data []byte
-	var key KEYIN
-	var value VALUEIN
 	for {
 		data, err = ctx.readline()
 		if err != nil {
+			var key KEYIN
+			var value VALUEIN
 			return key, value, err
 		}
 		if len(data) != 0 {
 			break
 		}
 	}
+	ctx.raw = data
+	ctx.stats.recordsRead.Add(1)
+	key, value, err := ctx.parseKeyValue(data)
+	if err != nil {
+		ctx.stats.readErrors.Add(1)
+	}
+	return key, value, err
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) parseKeyValue(data []byte) (KEYIN, VALUEIN, error) {
+	var err error
+	var key KEYIN
+	var value VALUEIN
 	if ctx.noKeyIn {
 		value, err = ctx.valueInSerializer.Deserialize(data)
 	} else {
@@ -153,12 +374,60 @@ func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) readKeyValue() (KEYIN, VAL
 	return key, value, err
 }
 
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetCurrentRaw() []byte {
+	return ctx.raw
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) markKey() {
+	if ctx.heartbeat != nil && !ctx.noKeyIn {
+		keyBytes, _, _ := bytes.Cut(ctx.raw, []byte{'\t'})
+		ctx.heartbeat.setKey(keyBytes)
+	}
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) call(key KEYIN, fn func() error) error {
+	ctx.markKey()
+	if timeout := ctx.GetTaskTimeout(); timeout > 0 {
+		ctx.recordDeadline = time.Now().Add(timeout)
+		defer ctx.endRecord()
+	}
+	return ctx.protect(func() KEYIN { return key }, fn)
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) endRecord() {
+	if ctx.cancelRecord != nil {
+		ctx.cancelRecord()
+		ctx.recordCtx, ctx.cancelRecord = nil, nil
+	}
+	ctx.recordDeadline = time.Time{}
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) protect(key func() KEYIN, fn func() error) (err error) {
+	if !ctx.recoverPanic {
+		return fn()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewPanicError(r, key(), ctx.raw)
+		}
+	}()
+	return fn()
+}
+
 func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetCounter(group, counter string) Counter {
-	return NewDefaultCounter(group, counter)
+	return ctx.counters.Get(group, counter)
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetEnumCounter(counter fmt.Stringer) Counter {
+	return ctx.counters.GetEnum(counter)
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) BindCounters(v any) error {
+	return ctx.counters.Bind(v)
 }
 
 func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) SetStatus(msg string) {
-	fmt.Fprintf(os.Stderr, "reporter:status:%vn", msg)
+	ctx.counters.Reporter().Status(msg)
 }
 
 func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Check() error {
@@ -178,34 +447,89 @@ func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Check() error {
 }
 
 func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Write(key KEYOUT, value VALUEOUT) error {
+	err := ctx.write(key, value)
+	if err != nil {
+		ctx.stats.writeErrors.Add(1)
+		if ctx.writeErrorHandler != nil {
+			return ctx.writeErrorHandler(err, key, value)
+		}
+	}
+	return err
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) write(key KEYOUT, value VALUEOUT) error {
 	var keyData []byte
 	if !ctx.noKeyOut {
 		var err error
 		keyData, err = ctx.keyOutSerializer.Serialize(key)
+		if err == nil && ctx.saltKey != nil {
+			keyData, err = ctx.saltKey(keyData)
+		}
 		if err != nil {
-			return err
+			return &SerializeError{Err: err}
 		}
 	}
 	valueData, err := ctx.valueOutSerializer.Serialize(value)
 	if err != nil {
+		return &SerializeError{Err: err}
+	}
+	ctx.writerMutex.Lock()
+	defer ctx.writerMutex.Unlock()
+	if err := writeRecord(ctx.writer, keyData, valueData); err != nil {
 		return err
 	}
+	ctx.stats.recordsWritten.Add(1)
+	ctx.stats.bytesWritten.Add(int64(recordSize(keyData, valueData)))
+	return nil
+}
+
+// abort is the last resort after the grace period. Output is not flushed if
+// the run loop is blocked writing to a full stdout pipe.
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) abort() error {
+	var err error
+	if ctx.outputs != nil {
+		err = ctx.outputs.abort()
+	}
+	err = MergeErrors(err, ctx.counters.Flush())
+	if !ctx.writerMutex.TryLock() {
+		return MergeErrors(err, fmt.Errorf("writer is busy"))
+	}
+	return MergeErrors(err, ctx.writer.Flush())
+}
+
+func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Close() error {
+	ctx.stopHeartbeat()
+	ctx.cancelTask(context.Canceled)
+	var err error
+	if ctx.outputs != nil {
+		err = ctx.outputs.Close()
+	}
+	err = MergeErrors(err, ctx.counters.Flush())
+	ctx.writerMutex.Lock()
+	defer ctx.writerMutex.Unlock()
+	return MergeErrors(err, ctx.writer.Flush())
+}
+
+func recordSize(keyData, valueData []byte) int {
 	if len(keyData) != 0 {
-		if _, err := ctx.writer.Write(keyData); err != nil {
+		return len(keyData) + len(valueData) + 2
+	}
+	return len(valueData) + 1
+}
+
+func writeRecord(w *bufio.Writer, keyData, valueData []byte) error {
+	if len(keyData) != 0 {
+		if _, err := w.Write(keyData); err != nil {
 			return err
 		}
-		if err := ctx.writer.WriteByte('\t'); err != nil {
+		if err := w.WriteByte('\t'); err != nil {
 			return err
 		}
 	}
-	if _, err := ctx.writer.Write(valueData); err != nil {
+	if _, err := w.Write(valueData); err != nil {
 		return err
 	}
-	return ctx.writer.WriteByte('\n')
-}
-
-func (ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Close() error {
-	return ctx.writer.Flush()
+	return w.WriteByte('\n')
 }
 
 type NoneKey struct{}
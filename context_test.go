@@ -0,0 +1,40 @@
+package hadoop_streaming
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordContext(t *testing.T) {
+	ctx := NewContext[string, string, string, string](strings.NewReader(""), &strings.Builder{})
+	ctx.WithTaskTimeout(time.Minute)
+	if _, ok := ctx.GetContext().Deadline(); ok {
+		t.Errorf("task context has a deadline")
+	}
+	err := ctx.call("a", func() error {
+		deadline, ok := ctx.GetContext().Deadline()
+		if !ok || time.Until(deadline) > time.Minute {
+			t.Errorf("record deadline = %v, %v", deadline, ok)
+		}
+		if ctx.GetContext() != ctx.GetContext() {
+			t.Errorf("record context is rebuilt")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.recordCtx != nil || ctx.GetContext() != ctx.taskCtx {
+		t.Errorf("record context outlives the record")
+	}
+}
+
+func TestCallAllocs(t *testing.T) {
+	ctx := NewContext[string, string, string, string](strings.NewReader(""), &strings.Builder{})
+	ctx.WithTaskTimeout(time.Minute).WithRecoverPanic(true)
+	fn := func() error { return nil }
+	if allocs := testing.AllocsPerRun(100, func() { ctx.call("a", fn) }); allocs != 0 {
+		t.Errorf("call allocates %v times per record", allocs)
+	}
+}
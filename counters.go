@@ -0,0 +1,327 @@
+package hadoop_streaming
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const DEFAULT_COUNTER_FLUSH_INTERVAL = 10 * time.Second
+
+const FRAMEWORK_COUNTER_GROUP = "GoHadoopStreaming"
+
+const (
+	DEFAULT_MAX_COUNTERS   = 120
+	COUNTER_GROUP_NAME_MAX = 128
+	COUNTER_NAME_MAX       = 64
+	OTHER_COUNTER_GROUP    = "OTHER"
+	OTHER_COUNTER          = "OTHER"
+)
+
+const (
+	COUNTER_RECORDS_READ       = "RECORDS_READ"
+	COUNTER_RECORDS_WRITTEN    = "RECORDS_WRITTEN"
+	COUNTER_BYTES_READ         = "BYTES_READ"
+	COUNTER_BYTES_WRITTEN      = "BYTES_WRITTEN"
+	COUNTER_READ_ERRORS        = "READ_ERRORS"
+	COUNTER_WRITE_ERRORS       = "WRITE_ERRORS"
+	COUNTER_SKIPPED_RECORDS    = "SKIPPED_RECORDS"
+	COUNTER_KEY_GROUPS         = "KEY_GROUPS"
+	COUNTER_MAX_VALUES_PER_KEY = "MAX_VALUES_PER_KEY"
+	COUNTER_OUT_OF_ORDER_KEYS  = "OUT_OF_ORDER_KEYS"
+)
+
+type CounterCollector = func(set func(group, counter string, value int64))
+
+type counterName struct {
+	group   string
+	counter string
+}
+
+func newCounterName(group, counter string) counterName {
+	return counterName{
+		group:   SanitizeCounterName(group, COUNTER_GROUP_NAME_MAX),
+		counter: SanitizeCounterName(counter, COUNTER_NAME_MAX),
+	}
+}
+
+func SanitizeCounterName(name string, maxLen int) string {
+	clean := true
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c == ',' || c < ' ' || c == 0x7f {
+			clean = false
+			break
+		}
+	}
+	if !clean {
+		name = strings.Map(func(r rune) rune {
+			if r == ',' || r < ' ' || r == 0x7f {
+				return '_'
+			}
+			return r
+		}, name)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "UNKNOWN"
+	}
+	if maxLen > 0 && len(name) > maxLen {
+		name = strings.ToValidUTF8(name[:maxLen], "")
+	}
+	return name
+}
+
+type counterValue struct {
+	total    int64
+	reported int64
+}
+
+type Counters struct {
+	mutex         sync.Mutex
+	reporter      *Reporter
+	flushInterval time.Duration
+	maxCounters   int
+	lastFlush     time.Time
+	names         []counterName
+	values        map[counterName]*counterValue
+	collected     map[counterName]int64
+	collectors    []CounterCollector
+}
+
+func NewCounters() *Counters {
+	return &Counters{
+		reporter:      NewReporter(os.Stderr),
+		flushInterval: DEFAULT_COUNTER_FLUSH_INTERVAL,
+		maxCounters:   DEFAULT_MAX_COUNTERS,
+		lastFlush:     time.Now(),
+		values:        make(map[counterName]*counterValue),
+		collected:     make(map[counterName]int64),
+	}
+}
+
+func (counters *Counters) WithReporter(reporter io.Writer) *Counters {
+	counters.reporter = NewReporter(reporter)
+	return counters
+}
+
+func (counters *Counters) Reporter() *Reporter {
+	return counters.reporter
+}
+
+func (counters *Counters) WithFlushInterval(flushInterval time.Duration) *Counters {
+	counters.flushInterval = flushInterval
+	return counters
+}
+
+func (counters *Counters) WithMaxCounters(maxCounters int) *Counters {
+	counters.maxCounters = maxCounters
+	return counters
+}
+
+func (counters *Counters) AddCollector(collector CounterCollector) *Counters {
+	counters.mutex.Lock()
+	defer counters.mutex.Unlock()
+	counters.collectors = append(counters.collectors, collector)
+	return counters
+}
+
+func (counters *Counters) Get(group, counter string) *BatchCounter {
+	return &BatchCounter{
+		counters: counters,
+		group:    group,
+		counter:  counter,
+	}
+}
+
+func (counters *Counters) GetEnum(counter fmt.Stringer) *BatchCounter {
+	return counters.Get(enumCounterGroup(counter), counter.String())
+}
+
+func (counters *Counters) Bind(v any) error {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind target must be a non-nil struct pointer, got %T", v)
+	}
+	target := ptr.Elem()
+	targetType := target.Type()
+	counterType := reflect.TypeOf((*Counter)(nil)).Elem()
+	batchCounterType := reflect.TypeOf((*BatchCounter)(nil))
+	for i := 0; i < targetType.NumField(); i++ {
+		field := targetType.Field(i)
+		if !field.IsExported() || (field.Type != counterType && field.Type != batchCounterType) {
+			continue
+		}
+		name := field.Tag.Get("counter")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		group := field.Tag.Get("group")
+		if group == "" {
+			group = targetType.Name()
+		}
+		target.Field(i).Set(reflect.ValueOf(counters.Get(group, name)))
+	}
+	return nil
+}
+
+func (counters *Counters) Increment(group, counter string, amount int64) error {
+	counters.mutex.Lock()
+	defer counters.mutex.Unlock()
+	counters.limitedValue(newCounterName(group, counter)).total += amount
+	if time.Since(counters.lastFlush) >= counters.flushInterval {
+		return counters.flush()
+	}
+	return nil
+}
+
+func (counters *Counters) limitedValue(name counterName) *counterValue {
+	if _, ok := counters.values[name]; !ok && counters.maxCounters > 0 &&
+		len(counters.values) >= counters.maxCounters-1 {
+		name = counterName{group: OTHER_COUNTER_GROUP, counter: OTHER_COUNTER}
+	}
+	return counters.valueOf(name)
+}
+
+func (counters *Counters) valueOf(name counterName) *counterValue {
+	value, ok := counters.values[name]
+	if !ok {
+		value = &counterValue{}
+		counters.values[name] = value
+		counters.names = append(counters.names, name)
+	}
+	return value
+}
+
+// collect adds what collectors report since the last call, so that their
+// counters are capped and folded into OTHER like any other counter.
+func (counters *Counters) collect() {
+	for _, collector := range counters.collectors {
+		collector(func(group, counter string, total int64) {
+			name := newCounterName(group, counter)
+			delta := total - counters.collected[name]
+			counters.collected[name] = total
+			counters.limitedValue(name).total += delta
+		})
+	}
+}
+
+func (counters *Counters) Value(group, counter string) int64 {
+	counters.mutex.Lock()
+	defer counters.mutex.Unlock()
+	counters.collect()
+	if value, ok := counters.values[newCounterName(group, counter)]; ok {
+		return value.total
+	}
+	return 0
+}
+
+func (counters *Counters) Values() map[string]map[string]int64 {
+	counters.mutex.Lock()
+	defer counters.mutex.Unlock()
+	counters.collect()
+	values := make(map[string]map[string]int64)
+	for name, value := range counters.values {
+		if _, ok := values[name.group]; !ok {
+			values[name.group] = make(map[string]int64)
+		}
+		values[name.group][name.counter] = value.total
+	}
+	return values
+}
+
+func (counters *Counters) Flush() error {
+	counters.mutex.Lock()
+	defer counters.mutex.Unlock()
+	return counters.flush()
+}
+
+func (counters *Counters) flush() error {
+	counters.lastFlush = time.Now()
+	counters.collect()
+	for _, name := range counters.names {
+		value := counters.values[name]
+		delta := value.total - value.reported
+		if delta == 0 {
+			continue
+		}
+		if err := counters.reporter.Counter(name.group, name.counter, delta); err != nil {
+			return err
+		}
+		value.reported = value.total
+	}
+	return nil
+}
+
+type BatchCounter struct {
+	counters *Counters
+	group    string
+	counter  string
+}
+
+func (counter *BatchCounter) Increment(amount int) {
+	counter.counters.Increment(counter.group, counter.counter, int64(amount))
+}
+
+func (counter *BatchCounter) Value() int64 {
+	return counter.counters.Value(counter.group, counter.counter)
+}
+
+type CounterGroup interface {
+	CounterGroup() string
+}
+
+func enumCounterGroup(counter fmt.Stringer) string {
+	if group, ok := counter.(CounterGroup); ok {
+		return group.CounterGroup()
+	}
+	return reflect.TypeOf(counter).String()
+}
+
+type frameworkStats struct {
+	recordsRead     atomic.Int64
+	recordsWritten  atomic.Int64
+	bytesRead       atomic.Int64
+	bytesWritten    atomic.Int64
+	readErrors      atomic.Int64
+	writeErrors     atomic.Int64
+	skippedRecords  atomic.Int64
+	keyGroups       atomic.Int64
+	maxValuesPerKey atomic.Int64
+	outOfOrderKeys  atomic.Int64
+}
+
+func (stats *frameworkStats) updateMaxValuesPerKey(values int64) {
+	if values > stats.maxValuesPerKey.Load() {
+		stats.maxValuesPerKey.Store(values)
+	}
+}
+
+func (stats *frameworkStats) collect(set func(group, counter string, value int64)) {
+	for _, stat := range []struct {
+		counter string
+		value   *atomic.Int64
+	}{
+		{COUNTER_RECORDS_READ, &stats.recordsRead},
+		{COUNTER_RECORDS_WRITTEN, &stats.recordsWritten},
+		{COUNTER_BYTES_READ, &stats.bytesRead},
+		{COUNTER_BYTES_WRITTEN, &stats.bytesWritten},
+		{COUNTER_READ_ERRORS, &stats.readErrors},
+		{COUNTER_WRITE_ERRORS, &stats.writeErrors},
+		{COUNTER_SKIPPED_RECORDS, &stats.skippedRecords},
+		{COUNTER_KEY_GROUPS, &stats.keyGroups},
+		{COUNTER_MAX_VALUES_PER_KEY, &stats.maxValuesPerKey},
+		{COUNTER_OUT_OF_ORDER_KEYS, &stats.outOfOrderKeys},
+	} {
+		if value := stat.value.Load(); value != 0 {
+			set(FRAMEWORK_COUNTER_GROUP, stat.counter, value)
+		}
+	}
+}
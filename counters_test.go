@@ -0,0 +1,86 @@
+package hadoop_streaming
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitizeCounterName(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxLen int
+		want   string
+	}{
+		{"records", 0, "records"},
+		{"a,b", 0, "a_b"},
+		{"line\nbreak\t", 0, "line_break_"},
+		{"  padded  ", 0, "padded"},
+		{"", 0, "UNKNOWN"},
+		{" \x7f ", 0, "_"},
+		{"abcdef", 4, "abcd"},
+		{"aéb", 2, "a"},
+	}
+	for _, test := range tests {
+		if got := SanitizeCounterName(test.name, test.maxLen); got != test.want {
+			t.Errorf("SanitizeCounterName(%q, %v) = %q, want %q", test.name, test.maxLen, got, test.want)
+		}
+	}
+}
+
+func TestCountersFoldIntoOther(t *testing.T) {
+	var out strings.Builder
+	counters := NewCounters().WithReporter(&out).WithFlushInterval(time.Hour).WithMaxCounters(5)
+	builtin := int64(1)
+	counters.AddCollector(func(set func(group, counter string, value int64)) {
+		for i := 0; i < 4; i++ {
+			set(FRAMEWORK_COUNTER_GROUP, fmt.Sprint("BUILTIN_", i), builtin)
+		}
+	})
+	for i := 0; i < 10; i++ {
+		if err := counters.Increment("user", fmt.Sprint("c", i), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := counters.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	builtin = 2
+	values := counters.Values()
+	distinct := 0
+	for _, group := range values {
+		distinct += len(group)
+	}
+	if distinct != 5 {
+		t.Errorf("distinct counters = %v, want 5: %v", distinct, values)
+	}
+	if other := values[OTHER_COUNTER_GROUP][OTHER_COUNTER]; other != 6+4*2 {
+		t.Errorf("OTHER = %v, want %v", other, 6+4*2)
+	}
+	if err := counters.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "reporter:counter:user,c0,1\n" +
+		"reporter:counter:user,c1,1\n" +
+		"reporter:counter:user,c2,1\n" +
+		"reporter:counter:user,c3,1\n" +
+		"reporter:counter:OTHER,OTHER,10\n" +
+		"reporter:counter:OTHER,OTHER,4\n"
+	if out.String() != want {
+		t.Errorf("reported %q, want %q", out.String(), want)
+	}
+}
+
+func TestCountersSanitizeNames(t *testing.T) {
+	var out strings.Builder
+	counters := NewCounters().WithReporter(&out)
+	counters.Get("my,group", "bad\ncounter").Increment(2)
+	counters.Get("my_group", "bad_counter").Increment(3)
+	if err := counters.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "reporter:counter:my_group,bad_counter,5\n"; out.String() != want {
+		t.Errorf("reported %q, want %q", out.String(), want)
+	}
+}
@@ -12,7 +12,7 @@ type Config struct {
 	SkipErr bool
 }
 
-type Mapper[K comparable, V any] struct {
+type Mapper[K, V any] struct {
 	*mr.DefaultMapper[K, V, K, V]
 	config *Config
 }
@@ -30,14 +30,14 @@ func (mapper *Mapper[K, V]) Map(key K, value V,
 	return ctx.Write(key, value)
 }
 
-func NewMapper[K comparable, V any](config *Config) *Mapper[K, V] {
+func NewMapper[K, V any](config *Config) *Mapper[K, V] {
 	return &Mapper[K, V]{
 		DefaultMapper: mr.NewDefaultMapper[K, V, K, V](),
 		config:        config,
 	}
 }
 
-type Reducer[K comparable, V any] struct {
+type Reducer[K, V any] struct {
 	*mr.DefaultReducer[K, V, K, V]
 	config *Config
 }
@@ -62,31 +62,19 @@ func (reducer *Reducer[K, V]) Reduce(key K, values mr.Iterator[V],
 	return nil
 }
 
-func NewReducer[K comparable, V any](config *Config) *Reducer[K, V] {
+func NewReducer[K, V any](config *Config) *Reducer[K, V] {
 	return &Reducer[K, V]{
 		DefaultReducer: mr.NewDefaultReducer[K, V, K, V](),
 		config:         config,
 	}
 }
 
-func NewMapperRunner[K comparable, V any](config *Config) func() error {
-	return func() error {
-		mapper := NewMapper[K, V](config)
-		ctx := mapper.NewContext(os.Stdin, os.Stdout)
-		err := mr.RunMapper(mapper, ctx)
-		err2 := ctx.Close()
-		return mr.MergeErrors(err, err2)
-	}
+func NewMapperRunner[K, V any](config *Config) mr.Runner {
+	return mr.NewMapperRunner[K, V, K, V](NewMapper[K, V](config))
 }
 
-func NewReducerRunner[K comparable, V any](config *Config) func() error {
-	return func() error {
-		reducer := NewReducer[K, V](config)
-		ctx := reducer.NewContext(os.Stdin, os.Stdout)
-		err := mr.RunReducer(reducer, ctx)
-		err2 := ctx.Close()
-		return mr.MergeErrors(err, err2)
-	}
+func NewReducerRunner[K, V any](config *Config) mr.Runner {
+	return mr.NewReducerRunner[K, V, K, V](NewReducer[K, V](config))
 }
 
 func main() {
@@ -107,7 +95,7 @@ func main() {
 	}
 
 	app := mr.NewApplication()
-	err := app.WithMapper(func() error {
+	app.WithMapper(func() error {
 		if *containKey {
 			switch *typ {
 			case "bool":
@@ -269,9 +257,7 @@ func main() {
 			}
 		}
 		return fmt.Errorf("not support type %v", *typ)
-	}).Run(mode)
+	})
 
-	if err != nil {
-		println(err.Error())
-	}
+	os.Exit(app.Main(mode))
 }
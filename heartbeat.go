@@ -0,0 +1,83 @@
+package hadoop_streaming
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const HEARTBEAT_KEY_MAX = 64
+
+type heartbeat struct {
+	interval time.Duration
+	reporter *Reporter
+	counters *Counters
+	stats    *frameworkStats
+	progress func() Progress
+	key      atomic.Pointer[[]byte]
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func startHeartbeat(interval time.Duration, reporter *Reporter,
+	counters *Counters, stats *frameworkStats, progress func() Progress) *heartbeat {
+	hb := &heartbeat{
+		interval: interval,
+		reporter: reporter,
+		counters: counters,
+		stats:    stats,
+		progress: progress,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go hb.run()
+	return hb
+}
+
+func (hb *heartbeat) setKey(key []byte) {
+	hb.key.Store(&key)
+}
+
+func (hb *heartbeat) run() {
+	defer close(hb.done)
+	ticker := time.NewTicker(hb.interval)
+	defer ticker.Stop()
+	lastTime := time.Now()
+	lastRecords := hb.stats.recordsRead.Load()
+	for {
+		select {
+		case <-hb.stop:
+			return
+		case now := <-ticker.C:
+			records := hb.stats.recordsRead.Load()
+			rate := float64(records-lastRecords) / now.Sub(lastTime).Seconds()
+			lastTime, lastRecords = now, records
+			hb.reporter.Status(hb.status(records, rate))
+			hb.counters.Flush()
+		}
+	}
+}
+
+func (hb *heartbeat) status(records int64, rate float64) string {
+	msg := fmt.Sprintf("records=%v rate=%.1f/s", records, rate)
+	if progress := hb.progress(); progress.Known() {
+		msg += " " + progress.String()
+	}
+	if key := hb.key.Load(); key != nil {
+		keyBytes := *key
+		if len(keyBytes) > HEARTBEAT_KEY_MAX {
+			keyBytes = keyBytes[:HEARTBEAT_KEY_MAX]
+		}
+		msg += fmt.Sprintf(" key=%q", keyBytes)
+	}
+	return msg
+}
+
+func (hb *heartbeat) Stop() {
+	hb.once.Do(func() {
+		close(hb.stop)
+		<-hb.done
+	})
+}
@@ -0,0 +1,133 @@
+package hadoop_streaming
+
+import (
+	"container/heap"
+	"sort"
+)
+
+type HeavyHitter struct {
+	Key   string
+	Count int64
+	Error int64
+}
+
+type heavyHitterEntry struct {
+	HeavyHitter
+	seq   int64
+	index int
+}
+
+type heavyHitterHeap []*heavyHitterEntry
+
+func (h heavyHitterHeap) Len() int {
+	return len(h)
+}
+
+func (h heavyHitterHeap) Less(i, j int) bool {
+	if h[i].Count != h[j].Count {
+		return h[i].Count < h[j].Count
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h heavyHitterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *heavyHitterHeap) Push(x any) {
+	entry := x.(*heavyHitterEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *heavyHitterHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// HeavyHitters is a SpaceSaving sketch with deterministic eviction.
+type HeavyHitters struct {
+	capacity int
+	total    int64
+	entries  map[string]*heavyHitterEntry
+	heap     heavyHitterHeap
+}
+
+func NewHeavyHitters(capacity int) *HeavyHitters {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &HeavyHitters{
+		capacity: capacity,
+		entries:  make(map[string]*heavyHitterEntry, capacity),
+		heap:     make(heavyHitterHeap, 0, capacity),
+	}
+}
+
+func (hh *HeavyHitters) Add(key []byte) int64 {
+	hh.total++
+	if entry, ok := hh.entries[string(key)]; ok {
+		entry.Count++
+		entry.seq = hh.total
+		heap.Fix(&hh.heap, entry.index)
+		return entry.Count
+	}
+	if len(hh.heap) < hh.capacity {
+		entry := &heavyHitterEntry{
+			HeavyHitter: HeavyHitter{Key: string(key), Count: 1},
+			seq:         hh.total,
+		}
+		hh.entries[entry.Key] = entry
+		heap.Push(&hh.heap, entry)
+		return entry.Count
+	}
+	entry := hh.heap[0]
+	delete(hh.entries, entry.Key)
+	entry.Key = string(key)
+	entry.Error = entry.Count
+	entry.Count++
+	entry.seq = hh.total
+	hh.entries[entry.Key] = entry
+	heap.Fix(&hh.heap, 0)
+	return entry.Count
+}
+
+func (hh *HeavyHitters) Count(key []byte) int64 {
+	if entry, ok := hh.entries[string(key)]; ok {
+		return entry.Count
+	}
+	return 0
+}
+
+func (hh *HeavyHitters) Total() int64 {
+	return hh.total
+}
+
+func (hh *HeavyHitters) IsHeavy(key []byte, fraction float64) bool {
+	entry, ok := hh.entries[string(key)]
+	if !ok {
+		return false
+	}
+	return float64(entry.Count-entry.Error) >= fraction*float64(hh.total)
+}
+
+func (hh *HeavyHitters) Top(n int) []HeavyHitter {
+	top := make([]HeavyHitter, 0, len(hh.heap))
+	for _, entry := range hh.heap {
+		top = append(top, entry.HeavyHitter)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Key < top[j].Key
+	})
+	if n >= 0 && n < len(top) {
+		top = top[:n]
+	}
+	return top
+}
@@ -0,0 +1,139 @@
+package hadoop_streaming
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+func MatchInputFile(pattern, file string) (bool, error) {
+	filePath := file
+	if u, err := url.Parse(file); err == nil && u.Scheme != "" {
+		filePath = u.Path
+	}
+	if strings.Contains(pattern, "/") {
+		if strings.Contains(pattern, "://") {
+			return path.Match(pattern, file)
+		}
+		return path.Match(pattern, filePath)
+	}
+	return path.Match(pattern, path.Base(filePath))
+}
+
+type inputRoute[T any] struct {
+	pattern string
+	target  T
+}
+
+type inputRoutes[T any] struct {
+	routes   []inputRoute[T]
+	fallback *T
+}
+
+func (routes *inputRoutes[T]) add(pattern string, target T) {
+	routes.routes = append(routes.routes, inputRoute[T]{pattern: pattern, target: target})
+}
+
+func (routes *inputRoutes[T]) match(file string) (T, error) {
+	var empty T
+	for _, route := range routes.routes {
+		ok, err := MatchInputFile(route.pattern, file)
+		if err != nil {
+			return empty, fmt.Errorf("invalid input pattern %q: %w", route.pattern, err)
+		}
+		if ok {
+			return route.target, nil
+		}
+	}
+	if routes.fallback != nil {
+		return *routes.fallback, nil
+	}
+	return empty, fmt.Errorf("no route for input file %q", file)
+}
+
+type InputRouter struct {
+	jobConf *JobConf
+	routes  inputRoutes[Runner]
+}
+
+func NewInputRouter() *InputRouter {
+	return &InputRouter{
+		jobConf: NewJobConf(),
+	}
+}
+
+func (router *InputRouter) WithJobConf(jobConf *JobConf) *InputRouter {
+	router.jobConf = jobConf
+	return router
+}
+
+func (router *InputRouter) Route(pattern string, runner Runner) *InputRouter {
+	router.routes.add(pattern, runner)
+	return router
+}
+
+func (router *InputRouter) Default(runner Runner) *InputRouter {
+	router.routes.fallback = &runner
+	return router
+}
+
+func (router *InputRouter) Run() error {
+	runner, err := router.routes.match(router.jobConf.InputFile())
+	if err != nil {
+		return err
+	}
+	return runner()
+}
+
+type RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] struct {
+	routes inputRoutes[Mapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]]
+	mapper Mapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]
+}
+
+func NewRoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT any]() *RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	return &RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]{}
+}
+
+func (mapper *RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Route(
+	pattern string, target Mapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) *RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	mapper.routes.add(pattern, target)
+	return mapper
+}
+
+func (mapper *RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Default(
+	target Mapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) *RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	mapper.routes.fallback = &target
+	return mapper
+}
+
+func (mapper *RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Setup(
+	ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+	target, err := mapper.routes.match(ctx.GetJobConf().InputFile())
+	if err != nil {
+		return err
+	}
+	mapper.mapper = target
+	return target.Setup(ctx)
+}
+
+func (mapper *RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Map(
+	key KEYIN, value VALUEIN, ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+	return mapper.mapper.Map(key, value, ctx)
+}
+
+func (mapper *RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Cleanup(
+	ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+	if mapper.mapper == nil {
+		return nil
+	}
+	return mapper.mapper.Cleanup(ctx)
+}
+
+func (mapper *RoutingMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) FallbackReadError(
+	err error, ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+	if mapper.mapper == nil {
+		return err
+	}
+	return mapper.mapper.FallbackReadError(err, ctx)
+}
@@ -0,0 +1,253 @@
+package hadoop_streaming
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type JobConf struct {
+	lookup func(string) (string, bool)
+}
+
+func NewJobConf() *JobConf {
+	return &JobConf{
+		lookup: os.LookupEnv,
+	}
+}
+
+func NewJobConfFromMap(conf map[string]string) *JobConf {
+	env := make(map[string]string, len(conf))
+	for name, value := range conf {
+		env[JobConfEnvName(name)] = value
+	}
+	return &JobConf{
+		lookup: func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		},
+	}
+}
+
+func JobConfEnvName(name string) string {
+	var env strings.Builder
+	for _, c := range []byte(name) {
+		if (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') {
+			env.WriteByte(c)
+		} else {
+			env.WriteByte('_')
+		}
+	}
+	return env.String()
+}
+
+func (conf *JobConf) Get(name string) (string, bool) {
+	return conf.lookup(JobConfEnvName(name))
+}
+
+func (conf *JobConf) GetString(name string, def string) string {
+	if value, ok := conf.Get(name); ok {
+		return value
+	}
+	return def
+}
+
+// getStringAlias reads name, then its deprecated mapred aliases.
+func (conf *JobConf) getStringAlias(def string, name string, aliases ...string) string {
+	for _, n := range append([]string{name}, aliases...) {
+		if value, ok := conf.Get(n); ok {
+			return value
+		}
+	}
+	return def
+}
+
+func (conf *JobConf) GetStrings(name string, def []string) []string {
+	value, ok := conf.Get(name)
+	if !ok {
+		return def
+	}
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func (conf *JobConf) GetInt(name string, def int) int {
+	return int(conf.GetInt64(name, int64(def)))
+}
+
+func (conf *JobConf) GetInt64(name string, def int64) int64 {
+	value, ok := conf.Get(name)
+	if !ok {
+		return def
+	}
+	num, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return def
+	}
+	return num
+}
+
+func (conf *JobConf) GetFloat64(name string, def float64) float64 {
+	value, ok := conf.Get(name)
+	if !ok {
+		return def
+	}
+	num, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return def
+	}
+	return num
+}
+
+func (conf *JobConf) GetBool(name string, def bool) bool {
+	value, ok := conf.Get(name)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+func (conf *JobConf) GetDuration(name string, unit time.Duration, def time.Duration) time.Duration {
+	value, ok := conf.Get(name)
+	if !ok {
+		return def
+	}
+	d, err := parseDuration(value, unit)
+	if err != nil {
+		return def
+	}
+	return d
+}
+
+func (conf *JobConf) TaskId() string {
+	return conf.GetString("mapreduce.task.id", "")
+}
+
+func (conf *JobConf) TaskAttemptId() string {
+	return conf.GetString("mapreduce.task.attempt.id", "")
+}
+
+func (conf *JobConf) TaskPartition() int {
+	return conf.GetInt("mapreduce.task.partition", 0)
+}
+
+func (conf *JobConf) IsMap() bool {
+	return conf.GetBool("mapreduce.task.ismap", true)
+}
+
+func (conf *JobConf) NumReduces() int {
+	return conf.GetInt("mapreduce.job.reduces", 1)
+}
+
+func (conf *JobConf) InputFile() string {
+	return conf.GetString("mapreduce.map.input.file", conf.GetString("map.input.file", ""))
+}
+
+func (conf *JobConf) InputStart() int64 {
+	return conf.GetInt64("mapreduce.map.input.start", conf.GetInt64("map.input.start", 0))
+}
+
+func (conf *JobConf) InputLength() int64 {
+	return conf.GetInt64("mapreduce.map.input.length", conf.GetInt64("map.input.length", 0))
+}
+
+func (conf *JobConf) TaskTimeout() time.Duration {
+	return conf.GetDuration("mapreduce.task.timeout", time.Millisecond, 0)
+}
+
+func (conf *JobConf) Bind(v any) error {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind target must be a non-nil struct pointer, got %T", v)
+	}
+	target := ptr.Elem()
+	targetType := target.Type()
+	for i := 0; i < targetType.NumField(); i++ {
+		field := targetType.Field(i)
+		name, ok := field.Tag.Lookup("conf")
+		if !ok || name == "-" || !field.IsExported() {
+			continue
+		}
+		value, ok := conf.Get(name)
+		if !ok {
+			if value, ok = field.Tag.Lookup("default"); !ok {
+				continue
+			}
+		}
+		if err := setConfValue(target.Field(i), value); err != nil {
+			return fmt.Errorf("bind %v to field %v: %w", name, field.Name, err)
+		}
+	}
+	return nil
+}
+
+func setConfValue(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := parseDuration(value, time.Millisecond)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num, err := strconv.ParseInt(strings.TrimSpace(value), 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(num)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num, err := strconv.ParseUint(strings.TrimSpace(value), 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(num)
+	case reflect.Float32, reflect.Float64:
+		num, err := strconv.ParseFloat(strings.TrimSpace(value), field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(num)
+	case reflect.Slice:
+		items := []string{}
+		if value != "" {
+			items = strings.Split(value, ",")
+		}
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setConfValue(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %v", field.Type())
+	}
+	return nil
+}
+
+func parseDuration(value string, unit time.Duration) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if num, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(num) * unit, nil
+	}
+	return time.ParseDuration(value)
+}
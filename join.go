@@ -0,0 +1,234 @@
+package hadoop_streaming
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type JoinType int
+
+const (
+	JOIN_INNER JoinType = iota
+	JOIN_LEFT
+	JOIN_RIGHT
+	JOIN_FULL_OUTER
+	JOIN_SEMI
+)
+
+const (
+	JOIN_TAG_LEFT  = 'L'
+	JOIN_TAG_RIGHT = 'R'
+)
+
+type JoinValue[L, R any] struct {
+	Left  *L
+	Right *R
+}
+
+func TagLeft[L, R any](value L) JoinValue[L, R] {
+	return JoinValue[L, R]{Left: &value}
+}
+
+func TagRight[L, R any](value R) JoinValue[L, R] {
+	return JoinValue[L, R]{Right: &value}
+}
+
+func (value JoinValue[L, R]) IsLeft() bool {
+	return value.Left != nil
+}
+
+func (value JoinValue[L, R]) newSerializer() any {
+	return NewJoinValueSerializer[L, R]()
+}
+
+type JoinValueSerializer[L, R any] struct {
+	leftSerializer  Serializer[L]
+	rightSerializer Serializer[R]
+}
+
+func NewJoinValueSerializer[L, R any]() *JoinValueSerializer[L, R] {
+	return &JoinValueSerializer[L, R]{
+		leftSerializer:  NewSerializer[L](),
+		rightSerializer: NewSerializer[R](),
+	}
+}
+
+func (s *JoinValueSerializer[L, R]) WithLeftSerializer(serializer Serializer[L]) *JoinValueSerializer[L, R] {
+	s.leftSerializer = serializer
+	return s
+}
+
+func (s *JoinValueSerializer[L, R]) WithRightSerializer(serializer Serializer[R]) *JoinValueSerializer[L, R] {
+	s.rightSerializer = serializer
+	return s
+}
+
+func (s *JoinValueSerializer[L, R]) Serialize(from JoinValue[L, R]) ([]byte, error) {
+	var tag byte
+	var data []byte
+	var err error
+	if from.Left != nil {
+		tag = JOIN_TAG_LEFT
+		data, err = s.leftSerializer.Serialize(*from.Left)
+	} else if from.Right != nil {
+		tag = JOIN_TAG_RIGHT
+		data, err = s.rightSerializer.Serialize(*from.Right)
+	} else {
+		return nil, fmt.Errorf("join value has neither left nor right")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tag, '\t'}, data...), nil
+}
+
+func (s *JoinValueSerializer[L, R]) Deserialize(to []byte) (JoinValue[L, R], error) {
+	var value JoinValue[L, R]
+	tag, data, ok := bytes.Cut(to, []byte{'\t'})
+	if !ok || len(tag) != 1 {
+		return value, fmt.Errorf("invalid join value: %q", to)
+	}
+	switch tag[0] {
+	case JOIN_TAG_LEFT:
+		left, err := s.leftSerializer.Deserialize(data)
+		if err != nil {
+			return value, err
+		}
+		value.Left = &left
+	case JOIN_TAG_RIGHT:
+		right, err := s.rightSerializer.Deserialize(data)
+		if err != nil {
+			return value, err
+		}
+		value.Right = &right
+	default:
+		return value, fmt.Errorf("unknown join tag: %q", tag)
+	}
+	return value, nil
+}
+
+// LeftFirstJoinOptions returns the streaming options that sort the tag that
+// JoinValueSerializer writes after the key as a second key field, so that
+// 'L' values precede 'R' values, while partitioning on the first field only.
+// The reducer still reads the tag as part of the value.
+func LeftFirstJoinOptions() []string {
+	return []string{
+		"-D", "stream.num.map.output.key.fields=2",
+		"-D", "mapreduce.job.output.key.comparator.class=org.apache.hadoop.mapreduce.lib.partition.KeyFieldBasedComparator",
+		"-D", "mapreduce.partition.keycomparator.options=-k1,1 -k2,2",
+		"-D", "mapreduce.partition.keypartitioner.options=-k1,1",
+		"-partitioner", "org.apache.hadoop.mapred.lib.KeyFieldBasedPartitioner",
+	}
+}
+
+type Joined[L, R any] struct {
+	Left  *L
+	Right *R
+}
+
+type JoinFunc[KEYIN, L, R, KEYOUT, VALUEOUT any] func(key KEYIN, joined Joined[L, R],
+	ctx *ReducerContext[KEYIN, JoinValue[L, R], KEYOUT, VALUEOUT]) error
+
+// JoinReducer buffers both sides of a key in memory unless WithLeftFirst is
+// set, in which case only the left side is buffered and the right side is
+// streamed. Put the smaller dataset on the left.
+type JoinReducer[KEYIN, L, R, KEYOUT, VALUEOUT any] struct {
+	*DefaultReducer[KEYIN, JoinValue[L, R], KEYOUT, VALUEOUT]
+	joinType  JoinType
+	leftFirst bool
+	join      JoinFunc[KEYIN, L, R, KEYOUT, VALUEOUT]
+}
+
+func NewJoinReducer[KEYIN, L, R, KEYOUT, VALUEOUT any](joinType JoinType,
+	join JoinFunc[KEYIN, L, R, KEYOUT, VALUEOUT]) *JoinReducer[KEYIN, L, R, KEYOUT, VALUEOUT] {
+	return &JoinReducer[KEYIN, L, R, KEYOUT, VALUEOUT]{
+		DefaultReducer: NewDefaultReducer[KEYIN, JoinValue[L, R], KEYOUT, VALUEOUT](),
+		joinType:       joinType,
+		join:           join,
+	}
+}
+
+// WithLeftFirst declares that the shuffle delivers all left values of a key
+// before its right values, so right values are streamed instead of buffered.
+// The job must sort on the key and the tag while partitioning on the key
+// alone, see LeftFirstJoinOptions.
+func (reducer *JoinReducer[KEYIN, L, R, KEYOUT, VALUEOUT]) WithLeftFirst(
+	leftFirst bool) *JoinReducer[KEYIN, L, R, KEYOUT, VALUEOUT] {
+	reducer.leftFirst = leftFirst
+	return reducer
+}
+
+func (reducer *JoinReducer[KEYIN, L, R, KEYOUT, VALUEOUT]) Reduce(key KEYIN,
+	values Iterator[JoinValue[L, R]], ctx *ReducerContext[KEYIN, JoinValue[L, R], KEYOUT, VALUEOUT]) error {
+	var lefts []L
+	var rights []R
+	sawRight := false
+	emitLefts := func() error {
+		for i := range lefts {
+			if err := reducer.join(key, Joined[L, R]{Left: &lefts[i]}, ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	emitRight := func(right R) error {
+		if len(lefts) == 0 {
+			if reducer.joinType == JOIN_RIGHT || reducer.joinType == JOIN_FULL_OUTER {
+				return reducer.join(key, Joined[L, R]{Right: &right}, ctx)
+			}
+			return nil
+		}
+		for i := range lefts {
+			if err := reducer.join(key, Joined[L, R]{Left: &lefts[i], Right: &right}, ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for values.HasNext() {
+		value := values.Next()
+		if value.Left != nil {
+			if reducer.leftFirst && sawRight {
+				return fmt.Errorf("left value after right value for key %v", key)
+			}
+			lefts = append(lefts, *value.Left)
+			continue
+		}
+		if value.Right == nil {
+			continue
+		}
+		if reducer.joinType == JOIN_SEMI {
+			if reducer.leftFirst && !sawRight {
+				if err := emitLefts(); err != nil {
+					return err
+				}
+			}
+			sawRight = true
+			continue
+		}
+		sawRight = true
+		if reducer.leftFirst {
+			if err := emitRight(*value.Right); err != nil {
+				return err
+			}
+		} else {
+			rights = append(rights, *value.Right)
+		}
+	}
+	for _, right := range rights {
+		if err := emitRight(right); err != nil {
+			return err
+		}
+	}
+	switch reducer.joinType {
+	case JOIN_SEMI:
+		if sawRight && !reducer.leftFirst {
+			return emitLefts()
+		}
+	case JOIN_LEFT, JOIN_FULL_OUTER:
+		if !sawRight {
+			return emitLefts()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package hadoop_streaming
+
+import (
+	"strings"
+	"testing"
+)
+
+func runJoin(joinType JoinType, leftFirst bool, input string) (string, error) {
+	var out strings.Builder
+	ctx := NewReducerContext[string, JoinValue[string, string], string, string](strings.NewReader(input), &out)
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	reducer := NewJoinReducer(joinType, func(key string, joined Joined[string, string],
+		ctx *ReducerContext[string, JoinValue[string, string], string, string]) error {
+		left, right := "-", "-"
+		if joined.Left != nil {
+			left = *joined.Left
+		}
+		if joined.Right != nil {
+			right = *joined.Right
+		}
+		return ctx.Write(key, left+"|"+right)
+	}).WithLeftFirst(leftFirst)
+	err := RunReducer[string, JoinValue[string, string], string, string](reducer, ctx)
+	err = MergeErrors(err, ctx.Close())
+	return out.String(), err
+}
+
+func TestJoinReducer(t *testing.T) {
+	buffered := "k1\tR\tr1\nk1\tL\tl1\nk1\tL\tl2\nk2\tL\tl3\nk3\tR\tr2\nk4\tR\tr3\nk4\tL\tl4\nk4\tR\tr4\n"
+	leftFirst := "k1\tL\tl1\nk1\tL\tl2\nk1\tR\tr1\nk2\tL\tl3\nk3\tR\tr2\nk4\tL\tl4\nk4\tR\tr3\nk4\tR\tr4\n"
+	tests := []struct {
+		joinType JoinType
+		want     string
+	}{
+		{JOIN_INNER, "k1\tl1|r1\nk1\tl2|r1\nk4\tl4|r3\nk4\tl4|r4\n"},
+		{JOIN_LEFT, "k1\tl1|r1\nk1\tl2|r1\nk2\tl3|-\nk4\tl4|r3\nk4\tl4|r4\n"},
+		{JOIN_RIGHT, "k1\tl1|r1\nk1\tl2|r1\nk3\t-|r2\nk4\tl4|r3\nk4\tl4|r4\n"},
+		{JOIN_FULL_OUTER, "k1\tl1|r1\nk1\tl2|r1\nk2\tl3|-\nk3\t-|r2\nk4\tl4|r3\nk4\tl4|r4\n"},
+		{JOIN_SEMI, "k1\tl1|-\nk1\tl2|-\nk4\tl4|-\n"},
+	}
+	for _, test := range tests {
+		for _, mode := range []struct {
+			leftFirst bool
+			input     string
+		}{{false, buffered}, {true, leftFirst}} {
+			out, err := runJoin(test.joinType, mode.leftFirst, mode.input)
+			if err != nil {
+				t.Fatalf("join %v leftFirst %v: %v", test.joinType, mode.leftFirst, err)
+			}
+			if out != test.want {
+				t.Errorf("join %v leftFirst %v: output = %q, want %q", test.joinType, mode.leftFirst, out, test.want)
+			}
+		}
+	}
+}
+
+func TestJoinReducerLeftAfterRight(t *testing.T) {
+	input := "k1\tL\tl1\nk1\tR\tr1\nk1\tL\tl2\n"
+	if _, err := runJoin(JOIN_INNER, true, input); err == nil ||
+		!strings.Contains(err.Error(), "left value after right value") {
+		t.Errorf("err = %v, want left value after right value", err)
+	}
+	if out, err := runJoin(JOIN_INNER, false, input); err != nil || out != "k1\tl1|r1\nk1\tl2|r1\n" {
+		t.Errorf("buffered join = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,103 @@
+package hadoop_streaming
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type foldedKey struct {
+	Name string
+}
+
+func (key foldedKey) Equal(other foldedKey) bool {
+	return strings.EqualFold(key.Name, other.Name)
+}
+
+type pairKey struct {
+	A, B int
+}
+
+func TestDefaultKeyEquality(t *testing.T) {
+	if equal := DefaultKeyEquality[string](); !equal("a", "a", nil, nil) || equal("a", "b", nil, nil) {
+		t.Errorf("string equality")
+	}
+	if equal := DefaultKeyEquality[pairKey](); !equal(pairKey{1, 2}, pairKey{1, 2}, nil, nil) ||
+		equal(pairKey{1, 2}, pairKey{2, 1}, nil, nil) {
+		t.Errorf("struct equality")
+	}
+	if equal := DefaultKeyEquality[foldedKey](); !equal(foldedKey{"A"}, foldedKey{"a"}, nil, nil) {
+		t.Errorf("Equal method not used")
+	}
+	equal := DefaultKeyEquality[[]int]()
+	if !equal([]int{1}, []int{2}, []byte("[1]"), []byte("[1]")) || equal([]int{1}, []int{1}, []byte("[1]"), []byte("[2]")) {
+		t.Errorf("slice keys should compare raw bytes")
+	}
+}
+
+func TestComparableKeyEqualityAllocs(t *testing.T) {
+	equal := DefaultKeyEquality[string]()
+	a, b := strings.Repeat("k", 32), strings.Repeat("k", 32)
+	if allocs := testing.AllocsPerRun(100, func() { equal(a, b, nil, nil) }); allocs != 0 {
+		t.Errorf("string key equality allocates %v times", allocs)
+	}
+}
+
+func TestSliceKeyGrouping(t *testing.T) {
+	var out strings.Builder
+	ctx := NewReducerContext[[]int, int, string, int](strings.NewReader("[1,2]\t1\n[1,2]\t2\n[3]\t5\n"), &out)
+	ctx.WithKeyInSerializer(&JsonSerializer[[]int]{})
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	var groups []int
+	for {
+		ok, err := ctx.NextKeyValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		sum := 0
+		for values := ctx.GetValues(); values.HasNext(); {
+			sum += values.Next()
+		}
+		groups = append(groups, sum)
+	}
+	if len(groups) != 2 || groups[0] != 3 || groups[1] != 5 {
+		t.Errorf("groups = %v, want [3 5]", groups)
+	}
+}
+
+type anyKey struct {
+	V any
+}
+
+func TestInterfaceKeyGrouping(t *testing.T) {
+	if strictlyComparable(reflect.TypeOf(anyKey{})) || strictlyComparable(reflect.TypeOf([2]any{})) ||
+		!strictlyComparable(reflect.TypeOf(pairKey{})) {
+		t.Errorf("strictlyComparable")
+	}
+	var out strings.Builder
+	ctx := NewReducerContext[anyKey, int, string, int](
+		strings.NewReader("{\"V\":[1]}\t1\n{\"V\":[1]}\t2\n{\"V\":2}\t5\n"), &out)
+	ctx.WithKeyInSerializer(&JsonSerializer[anyKey]{})
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	var groups []int
+	for {
+		ok, err := ctx.NextKeyValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		sum := 0
+		for values := ctx.GetValues(); values.HasNext(); {
+			sum += values.Next()
+		}
+		groups = append(groups, sum)
+	}
+	if len(groups) != 2 || groups[0] != 3 || groups[1] != 5 {
+		t.Errorf("groups = %v, want [3 5]", groups)
+	}
+}
@@ -0,0 +1,190 @@
+package hadoop_streaming
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type KeySpec struct {
+	BeginField int
+	BeginChar  int
+	EndField   int
+	EndChar    int
+	Numeric    bool
+	Reverse    bool
+}
+
+func newKeySpec() KeySpec {
+	return KeySpec{BeginField: 1, BeginChar: 1}
+}
+
+type KeyFields struct {
+	separator   []byte
+	specs       []KeySpec
+	keySpecSeen bool
+}
+
+func ParseKeyFields(options string) (*KeyFields, error) {
+	fields := &KeyFields{
+		separator: []byte{'\t'},
+	}
+	args := strings.Fields(options)
+	if len(args) == 0 {
+		return fields, nil
+	}
+	global := newKeySpec()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-n":
+			global.Numeric = true
+		case "-r":
+			global.Reverse = true
+		case "-nr":
+			global.Numeric = true
+			global.Reverse = true
+		}
+		if !strings.HasPrefix(arg, "-k") {
+			continue
+		}
+		keyArgs := arg[2:]
+		if keyArgs == "" {
+			if i+1 >= len(args) {
+				continue
+			}
+			i++
+			keyArgs = args[i]
+		}
+		spec, err := parseKeySpec(keyArgs)
+		if err != nil {
+			return nil, err
+		}
+		fields.specs = append(fields.specs, spec)
+		fields.keySpecSeen = true
+	}
+	for i := range fields.specs {
+		if !fields.specs[i].Numeric && !fields.specs[i].Reverse {
+			fields.specs[i].Numeric = global.Numeric
+			fields.specs[i].Reverse = global.Reverse
+		}
+	}
+	if len(fields.specs) == 0 {
+		fields.specs = append(fields.specs, global)
+	}
+	return fields, nil
+}
+
+func parseKeySpec(keyArgs string) (KeySpec, error) {
+	spec := newKeySpec()
+	invalid := fmt.Errorf("invalid -k argument %q, must be of the form -k pos1,[pos2], "+
+		"where pos is of the form f[.c]nr", keyArgs)
+	begin, end, hasEnd := strings.Cut(keyArgs, ",")
+	var err error
+	if spec.BeginField, spec.BeginChar, err = parseKeyPos(&spec, begin, 1); err != nil {
+		return spec, invalid
+	}
+	if hasEnd {
+		if spec.EndField, spec.EndChar, err = parseKeyPos(&spec, end, 0); err != nil {
+			return spec, invalid
+		}
+	}
+	return spec, nil
+}
+
+func parseKeyPos(spec *KeySpec, pos string, defaultChar int) (int, int, error) {
+	i := strings.IndexFunc(pos, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(pos)
+	}
+	field, err := strconv.Atoi(pos[:i])
+	if err != nil {
+		return 0, 0, err
+	}
+	pos = pos[i:]
+	char := defaultChar
+	if strings.HasPrefix(pos, ".") {
+		pos = pos[1:]
+		i = strings.IndexFunc(pos, func(r rune) bool { return r < '0' || r > '9' })
+		if i < 0 {
+			i = len(pos)
+		}
+		if char, err = strconv.Atoi(pos[:i]); err != nil {
+			return 0, 0, err
+		}
+		pos = pos[i:]
+	}
+	for _, c := range pos {
+		switch c {
+		case 'n':
+			spec.Numeric = true
+		case 'r':
+			spec.Reverse = true
+		default:
+			return 0, 0, fmt.Errorf("invalid key option %q", c)
+		}
+	}
+	return field, char, nil
+}
+
+func (fields *KeyFields) WithSeparator(separator string) *KeyFields {
+	fields.separator = []byte(separator)
+	return fields
+}
+
+func (fields *KeyFields) Specs() []KeySpec {
+	return fields.specs
+}
+
+func (fields *KeyFields) wordLengths(b []byte) []int {
+	if !fields.keySpecSeen {
+		return []int{1}
+	}
+	lengths := []int{0}
+	start := 0
+	for {
+		pos := bytes.Index(b[start:], fields.separator)
+		if pos < 0 {
+			break
+		}
+		lengths = append(lengths, pos)
+		start += pos + len(fields.separator)
+	}
+	lengths = append(lengths, len(b)-start)
+	lengths[0] = len(lengths) - 1
+	return lengths
+}
+
+func (fields *KeyFields) startOffset(b []byte, lengths []int, spec KeySpec) int {
+	if lengths[0] >= spec.BeginField {
+		position := 0
+		for i := 1; i < spec.BeginField; i++ {
+			position += lengths[i] + len(fields.separator)
+		}
+		if position+spec.BeginChar <= len(b) {
+			return position + spec.BeginChar - 1
+		}
+	}
+	return -1
+}
+
+func (fields *KeyFields) endOffset(b []byte, lengths []int, spec KeySpec) int {
+	if spec.EndField == 0 {
+		return len(b) - 1
+	}
+	if lengths[0] >= spec.EndField {
+		position := 0
+		i := 1
+		for ; i < spec.EndField; i++ {
+			position += lengths[i] + len(fields.separator)
+		}
+		if spec.EndChar == 0 {
+			position += lengths[i]
+		}
+		if position+spec.EndChar <= len(b) {
+			return position + spec.EndChar - 1
+		}
+	}
+	return len(b) - 1
+}
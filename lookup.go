@@ -0,0 +1,150 @@
+package hadoop_streaming
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+type DuplicatePolicy int
+
+const (
+	DUPLICATE_KEEP_FIRST DuplicatePolicy = iota
+	DUPLICATE_KEEP_LAST
+	DUPLICATE_ERROR
+)
+
+const LOOKUP_ENTRY_OVERHEAD = 64
+
+type lookupEntry[V any] struct {
+	value V
+	size  int64
+}
+
+type LookupTable[K comparable, V any] struct {
+	keySerializer   Serializer[K]
+	valueSerializer Serializer[V]
+	duplicatePolicy DuplicatePolicy
+	maxBytes        int64
+	missCounter     Counter
+	table           map[K]lookupEntry[V]
+	bytes           int64
+}
+
+func NewLookupTable[K comparable, V any]() *LookupTable[K, V] {
+	return &LookupTable[K, V]{
+		keySerializer:   NewSerializer[K](),
+		valueSerializer: NewSerializer[V](),
+		table:           make(map[K]lookupEntry[V]),
+	}
+}
+
+func (table *LookupTable[K, V]) WithKeySerializer(serializer Serializer[K]) *LookupTable[K, V] {
+	table.keySerializer = serializer
+	return table
+}
+
+func (table *LookupTable[K, V]) WithValueSerializer(serializer Serializer[V]) *LookupTable[K, V] {
+	table.valueSerializer = serializer
+	return table
+}
+
+func (table *LookupTable[K, V]) WithDuplicatePolicy(policy DuplicatePolicy) *LookupTable[K, V] {
+	table.duplicatePolicy = policy
+	return table
+}
+
+func (table *LookupTable[K, V]) WithMaxBytes(maxBytes int64) *LookupTable[K, V] {
+	table.maxBytes = maxBytes
+	return table
+}
+
+func (table *LookupTable[K, V]) WithMissCounter(counter Counter) *LookupTable[K, V] {
+	table.missCounter = counter
+	return table
+}
+
+func (table *LookupTable[K, V]) Load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := table.LoadFrom(file); err != nil {
+		return fmt.Errorf("load %v: %w", path, err)
+	}
+	return nil
+}
+
+func (table *LookupTable[K, V]) LoadFrom(r io.Reader) error {
+	if table.keySerializer == nil {
+		return fmt.Errorf("key serializer is nil")
+	}
+	if table.valueSerializer == nil {
+		return fmt.Errorf("value serializer is nil")
+	}
+	var loadErr error
+	lineNo := 0
+	ReadLines(r, func(data []byte, err error) bool {
+		lineNo++
+		if err != nil && err != io.EOF {
+			loadErr = err
+			return false
+		}
+		if len(data) == 0 {
+			return true
+		}
+		if loadErr = table.add(data); loadErr != nil {
+			loadErr = fmt.Errorf("line %v: %w", lineNo, loadErr)
+			return false
+		}
+		return true
+	})
+	return loadErr
+}
+
+func (table *LookupTable[K, V]) add(data []byte) error {
+	keyBytes, valueBytes, _ := bytes.Cut(data, []byte{'\t'})
+	key, err := table.keySerializer.Deserialize(keyBytes)
+	if err != nil {
+		return err
+	}
+	old, ok := table.table[key]
+	if ok {
+		switch table.duplicatePolicy {
+		case DUPLICATE_KEEP_FIRST:
+			return nil
+		case DUPLICATE_ERROR:
+			return fmt.Errorf("duplicate key: %q", keyBytes)
+		}
+	}
+	value, err := table.valueSerializer.Deserialize(valueBytes)
+	if err != nil {
+		return err
+	}
+	size := int64(len(data) + LOOKUP_ENTRY_OVERHEAD)
+	total := table.bytes + size - old.size
+	if table.maxBytes > 0 && total > table.maxBytes {
+		return fmt.Errorf("lookup table exceeds max bytes %v", table.maxBytes)
+	}
+	table.bytes = total
+	table.table[key] = lookupEntry[V]{value: value, size: size}
+	return nil
+}
+
+func (table *LookupTable[K, V]) Get(key K) (V, bool) {
+	entry, ok := table.table[key]
+	if !ok && table.missCounter != nil {
+		table.missCounter.Increment(1)
+	}
+	return entry.value, ok
+}
+
+func (table *LookupTable[K, V]) Len() int {
+	return len(table.table)
+}
+
+func (table *LookupTable[K, V]) Bytes() int64 {
+	return table.bytes
+}
@@ -4,17 +4,17 @@ import (
 	"io"
 )
 
-type Mapper[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any] interface {
+type Mapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] interface {
 	Setup(ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
 	Map(key KEYIN, value VALUEIN, ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
 	Cleanup(ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
 	FallbackReadError(err error, ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
 }
 
-type DefaultMapper[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any] struct {
+type DefaultMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] struct {
 }
 
-func NewDefaultMapper[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any]() *DefaultMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+func NewDefaultMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT any]() *DefaultMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
 	return &DefaultMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]{}
 }
 
@@ -43,33 +43,53 @@ func (mapper *DefaultMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) NewContext(
 	return NewMapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT](r, w)
 }
 
-func RunMapper[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any](
+func RunMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
 	mapper Mapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT],
 	ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
 	if err := ctx.Check(); err != nil {
 		return err
 	}
+	ctx.startHeartbeat()
+	defer ctx.stopHeartbeat()
 	err := mapper.Setup(ctx)
 	if err != nil {
 		return err
 	}
 	for {
+		if ctx.Stopped() {
+			err = ErrStopped
+			break
+		}
 		var ok bool
 		ok, err = ctx.NextKeyValue()
-		if err != nil {
+		if err != nil && err != ErrStopped {
 			err = mapper.FallbackReadError(err, ctx)
 			if err == nil {
+				ctx.stats.skippedRecords.Add(1)
 				continue
 			}
 		}
 		if !ok {
 			break
 		}
-		err = mapper.Map(ctx.GetCurrentKey(), ctx.GetCurrentValue(), ctx)
+		key, value := ctx.GetCurrentKey(), ctx.GetCurrentValue()
+		err = ctx.call(key, func() error {
+			return mapper.Map(key, value, ctx)
+		})
 		if err != nil {
+			if _, ok := err.(*PanicError); ok {
+				err = mapper.FallbackReadError(err, ctx)
+				if err == nil {
+					ctx.stats.skippedRecords.Add(1)
+					continue
+				}
+			}
 			break
 		}
 	}
+	if err != nil && err != ErrStopped {
+		ctx.Fail(err)
+	}
 	err2 := mapper.Cleanup(ctx)
 	return MergeErrors(err, err2)
 }
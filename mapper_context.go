@@ -2,12 +2,12 @@ package hadoop_streaming
 
 import "io"
 
-type MapperContext[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any] struct {
+type MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] struct {
 	*Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]
 	value VALUEIN
 }
 
-func NewMapperContext[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any](
+func NewMapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
 	r io.Reader, w io.Writer) *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
 	var value VALUEIN
 	return &MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]{
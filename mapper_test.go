@@ -0,0 +1,42 @@
+package hadoop_streaming
+
+import (
+	"strings"
+	"testing"
+)
+
+type panickingMapper struct {
+	*DefaultMapper[string, int, string, int]
+}
+
+func (mapper *panickingMapper) Map(key string, value int,
+	ctx *MapperContext[string, int, string, int]) error {
+	if key == "boom" {
+		panic("boom")
+	}
+	return ctx.Write(key, value)
+}
+
+func (mapper *panickingMapper) FallbackReadError(err error,
+	ctx *MapperContext[string, int, string, int]) error {
+	return nil
+}
+
+func TestMapperRecoverPanic(t *testing.T) {
+	var out strings.Builder
+	ctx := NewMapperContext[string, int, string, int](strings.NewReader("a\t1\nboom\t2\nc\t3\n"), &out)
+	ctx.WithRecoverPanic(true)
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	if err := RunMapper[string, int, string, int](&panickingMapper{}, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\t1\nc\t3\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if skipped := ctx.stats.skippedRecords.Load(); skipped != 1 {
+		t.Errorf("skipped = %v, want 1", skipped)
+	}
+}
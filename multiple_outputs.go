@@ -0,0 +1,210 @@
+package hadoop_streaming
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sync"
+)
+
+type OutputOpener = func(path string) (io.WriteCloser, error)
+
+type namedOutputFile struct {
+	closer io.Closer
+	writer *bufio.Writer
+}
+
+type MultipleOutputs struct {
+	jobConf *JobConf
+	dir     string
+	opener  OutputOpener
+	mutex   sync.Mutex
+	files   map[string]*namedOutputFile
+}
+
+func NewMultipleOutputs(jobConf *JobConf) *MultipleOutputs {
+	return &MultipleOutputs{
+		jobConf: jobConf,
+		opener:  openLocalOutput,
+		files:   make(map[string]*namedOutputFile),
+	}
+}
+
+func (mos *MultipleOutputs) WithOutputDir(dir string) *MultipleOutputs {
+	mos.dir = dir
+	return mos
+}
+
+func (mos *MultipleOutputs) WithOpener(opener OutputOpener) *MultipleOutputs {
+	mos.opener = opener
+	return mos
+}
+
+func (mos *MultipleOutputs) OutputDir() string {
+	if mos.dir != "" {
+		return mos.dir
+	}
+	return mos.jobConf.GetString("mapreduce.task.output.dir", ".")
+}
+
+// Path names the file after the task id, which already carries the task type
+// and partition, e.g. name-task_1700000000000_0001_m_000003. Outside of a
+// task it falls back to name-m-00000.
+func (mos *MultipleOutputs) Path(name string) string {
+	if taskId := mos.jobConf.TaskId(); taskId != "" {
+		return fmt.Sprintf("%v/%v-%v", mos.OutputDir(), name, taskId)
+	}
+	taskType := "r"
+	if mos.jobConf.IsMap() {
+		taskType = "m"
+	}
+	return fmt.Sprintf("%v/%v-%v-%05d", mos.OutputDir(), name, taskType, mos.jobConf.TaskPartition())
+}
+
+func (mos *MultipleOutputs) write(name string, keyData, valueData []byte) error {
+	mos.mutex.Lock()
+	defer mos.mutex.Unlock()
+	file, ok := mos.files[name]
+	if !ok {
+		path := mos.Path(name)
+		w, err := mos.opener(path)
+		if err != nil {
+			return fmt.Errorf("open named output %v: %w", name, err)
+		}
+		file = &namedOutputFile{
+			closer: w,
+			writer: bufio.NewWriter(w),
+		}
+		mos.files[name] = file
+	}
+	return writeRecord(file.writer, keyData, valueData)
+}
+
+func (mos *MultipleOutputs) abort() error {
+	if !mos.mutex.TryLock() {
+		return fmt.Errorf("multiple outputs are busy")
+	}
+	errs := &MultiError{}
+	for _, file := range mos.files {
+		errs.AddErrs(file.writer.Flush())
+	}
+	return errs.AutoConvert()
+}
+
+func (mos *MultipleOutputs) Close() error {
+	mos.mutex.Lock()
+	defer mos.mutex.Unlock()
+	errs := &MultiError{}
+	for name, file := range mos.files {
+		errs.AddErrs(file.writer.Flush(), file.closer.Close())
+		delete(mos.files, name)
+	}
+	return errs.AutoConvert()
+}
+
+// openLocalOutput is the default opener. On YARN mapreduce.task.output.dir
+// is an hdfs:// attempt directory, which needs WithOpener(HadoopFsOpener) or
+// another opener; files written there are promoted when the task commits.
+func openLocalOutput(path string) (io.WriteCloser, error) {
+	if u, err := url.Parse(path); err == nil && u.Scheme != "" {
+		if u.Scheme != "file" {
+			return nil, fmt.Errorf("output %v is not local, set an opener with WithOpener, e.g. HadoopFsOpener", path)
+		}
+		path = u.Path
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+type hadoopFsOutput struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// HadoopFsOpener streams a named output to any Hadoop filesystem through
+// `hadoop fs -put`.
+func HadoopFsOpener(path string) (io.WriteCloser, error) {
+	cmd := exec.Command("hadoop", "fs", "-put", "-f", "-", path)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start hadoop fs -put %v: %w", path, err)
+	}
+	return &hadoopFsOutput{cmd: cmd, stdin: stdin}, nil
+}
+
+func (out *hadoopFsOutput) Write(data []byte) (int, error) {
+	return out.stdin.Write(data)
+}
+
+func (out *hadoopFsOutput) Close() error {
+	return MergeErrors(out.stdin.Close(), out.cmd.Wait())
+}
+
+type NamedOutput[K, V any] struct {
+	mos             *MultipleOutputs
+	name            string
+	noKey           bool
+	keySerializer   Serializer[K]
+	valueSerializer Serializer[V]
+}
+
+func NewNamedOutput[K, V any](mos *MultipleOutputs, name string) (*NamedOutput[K, V], error) {
+	if name == "" {
+		return nil, fmt.Errorf("named output name is empty")
+	}
+	for _, c := range []byte(name) {
+		if !((c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')) {
+			return nil, fmt.Errorf("invalid named output name: %q", name)
+		}
+	}
+	var key K
+	var noneKey NoneKey
+	return &NamedOutput[K, V]{
+		mos:             mos,
+		name:            name,
+		noKey:           reflect.TypeOf(key) == reflect.TypeOf(noneKey),
+		keySerializer:   NewSerializer[K](),
+		valueSerializer: NewSerializer[V](),
+	}, nil
+}
+
+func (out *NamedOutput[K, V]) WithKeySerializer(serializer Serializer[K]) *NamedOutput[K, V] {
+	out.keySerializer = serializer
+	return out
+}
+
+func (out *NamedOutput[K, V]) WithValueSerializer(serializer Serializer[V]) *NamedOutput[K, V] {
+	out.valueSerializer = serializer
+	return out
+}
+
+func (out *NamedOutput[K, V]) Name() string {
+	return out.name
+}
+
+func (out *NamedOutput[K, V]) Write(key K, value V) error {
+	var keyData []byte
+	if !out.noKey {
+		var err error
+		if keyData, err = out.keySerializer.Serialize(key); err != nil {
+			return &SerializeError{Err: err}
+		}
+	}
+	valueData, err := out.valueSerializer.Serialize(value)
+	if err != nil {
+		return &SerializeError{Err: err}
+	}
+	return out.mos.write(out.name, keyData, valueData)
+}
@@ -0,0 +1,138 @@
+package hadoop_streaming
+
+type Group[K, V any] struct {
+	Key    K
+	Values Iterator[V]
+}
+
+// PartitionReducer receives every key group of the task's input through a
+// single call, so that state can be carried across key boundaries.
+type PartitionReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] interface {
+	Setup(ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
+	ReducePartition(groups *GroupIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT],
+		ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
+	Cleanup(ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
+	FallbackReadError(err error, ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
+}
+
+type GroupIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] struct {
+	reducer PartitionReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT]
+	ctx     *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]
+	group   *Group[KEYIN, VALUEIN]
+	next    *Group[KEYIN, VALUEIN]
+	done    bool
+	err     error
+}
+
+func (iterator *GroupIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) HasNext() bool {
+	if iterator.next != nil {
+		return true
+	}
+	if iterator.done || iterator.err != nil {
+		return false
+	}
+	if err := iterator.finishGroup(); err != nil {
+		iterator.err = err
+		return false
+	}
+	ctx := iterator.ctx
+	for {
+		if ctx.Stopped() {
+			iterator.err = ErrStopped
+			return false
+		}
+		ok, err := ctx.NextKeyValue()
+		if err == ErrStopped {
+			iterator.err = err
+			return false
+		}
+		if err != nil {
+			readErr := err
+			err = iterator.reducer.FallbackReadError(err, ctx)
+			if err == nil {
+				ctx.skipRecord(readErr)
+				continue
+			}
+			iterator.err = err
+			return false
+		}
+		if !ok {
+			iterator.done = true
+			return false
+		}
+		break
+	}
+	ctx.stats.keyGroups.Add(1)
+	ctx.markKey()
+	iterator.group = &Group[KEYIN, VALUEIN]{
+		Key:    ctx.GetCurrentKey(),
+		Values: ctx.GetValues(),
+	}
+	iterator.next = iterator.group
+	return true
+}
+
+func (iterator *GroupIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Next() *Group[KEYIN, VALUEIN] {
+	group := iterator.next
+	iterator.next = nil
+	return group
+}
+
+func (iterator *GroupIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Err() error {
+	return iterator.err
+}
+
+func (iterator *GroupIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) key() KEYIN {
+	if iterator.group != nil {
+		return iterator.group.Key
+	}
+	var key KEYIN
+	return key
+}
+
+// finishGroup drains the values the callback left unread, so that the next
+// group starts at a new key.
+func (iterator *GroupIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) finishGroup() error {
+	if iterator.group == nil {
+		return nil
+	}
+	ctx := iterator.ctx
+	for values := iterator.group.Values; values.HasNext(); {
+		values.Next()
+	}
+	iterator.group = nil
+	ctx.stats.updateMaxValuesPerKey(ctx.groupValues)
+	return ctx.closeSpills()
+}
+
+func RunPartitionReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
+	reducer PartitionReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT],
+	ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+	if err := ctx.Check(); err != nil {
+		return err
+	}
+	ctx.startHeartbeat()
+	defer ctx.stopHeartbeat()
+	err := reducer.Setup(ctx)
+	if err != nil {
+		return err
+	}
+	groups := &GroupIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT]{
+		reducer: reducer,
+		ctx:     ctx,
+	}
+	err = ctx.protect(groups.key, func() error {
+		return reducer.ReducePartition(groups, ctx)
+	})
+	if err == nil {
+		err = groups.err
+	}
+	if err == nil {
+		err = groups.finishGroup()
+	}
+	if err != nil && err != ErrStopped {
+		ctx.Fail(err)
+	}
+	err2 := reducer.Cleanup(ctx)
+	return MergeErrors(err, err2)
+}
@@ -0,0 +1,62 @@
+package hadoop_streaming
+
+import (
+	"strings"
+	"testing"
+)
+
+// firstValueReducer writes the first value of each group.
+type firstValueReducer struct {
+	*DefaultReducer[string, int, string, int]
+	skipErrors bool
+}
+
+func (reducer *firstValueReducer) ReducePartition(groups *GroupIterator[string, int, string, int],
+	ctx *ReducerContext[string, int, string, int]) error {
+	for groups.HasNext() {
+		group := groups.Next()
+		if err := ctx.Write(group.Key, group.Values.Next()); err != nil {
+			return err
+		}
+	}
+	return groups.Err()
+}
+
+func (reducer *firstValueReducer) FallbackReadError(err error,
+	ctx *ReducerContext[string, int, string, int]) error {
+	if reducer.skipErrors {
+		return nil
+	}
+	return err
+}
+
+func TestRunPartitionReducer(t *testing.T) {
+	var out strings.Builder
+	ctx := NewReducerContext[string, int, string, int](
+		strings.NewReader("a\t1\na\t2\na\t3\nc\t4\nb\tx\nb\t5\nd\t6\nd\t7\n"), &out)
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	if err := RunPartitionReducer[string, int, string, int](&firstValueReducer{skipErrors: true}, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\t1\nc\t4\nb\t5\nd\t6\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	stats := ctx.stats
+	if stats.keyGroups.Load() != 4 || stats.skippedRecords.Load() != 1 || stats.maxValuesPerKey.Load() != 3 {
+		t.Errorf("key groups = %v, skipped = %v, max values per key = %v, want 4, 1, 3",
+			stats.keyGroups.Load(), stats.skippedRecords.Load(), stats.maxValuesPerKey.Load())
+	}
+}
+
+func TestRunPartitionReducerReadError(t *testing.T) {
+	ctx := NewReducerContext[string, int, string, int](
+		strings.NewReader("a\t1\na\tx\nb\t2\n"), &strings.Builder{})
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	err := RunPartitionReducer[string, int, string, int](&firstValueReducer{}, ctx)
+	if err == nil || !strings.Contains(err.Error(), `parsing "x"`) {
+		t.Errorf("err = %v, want the read error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package hadoop_streaming
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+type Partitioner interface {
+	Partition(key []byte, numPartitions int) int
+}
+
+func TextHashCode(b []byte) int32 {
+	hash := int32(1)
+	for _, c := range b {
+		hash = 31*hash + int32(int8(c))
+	}
+	return hash
+}
+
+func StringHashCode(s string) int32 {
+	hash := int32(0)
+	for _, c := range utf16.Encode([]rune(s)) {
+		hash = 31*hash + int32(c)
+	}
+	return hash
+}
+
+func hashPartition(hash int32, numPartitions int) int {
+	return int(hash&math.MaxInt32) % numPartitions
+}
+
+type HashPartitioner struct{}
+
+func NewHashPartitioner() *HashPartitioner {
+	return &HashPartitioner{}
+}
+
+func (partitioner *HashPartitioner) Partition(key []byte, numPartitions int) int {
+	return hashPartition(TextHashCode(key), numPartitions)
+}
+
+type KeyFieldBasedPartitioner struct {
+	fields *KeyFields
+	empty  bool
+}
+
+func NewKeyFieldBasedPartitioner(options string) (*KeyFieldBasedPartitioner, error) {
+	fields, err := ParseKeyFields(options)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyFieldBasedPartitioner{
+		fields: fields,
+		empty:  len(fields.Specs()) == 0,
+	}, nil
+}
+
+func (partitioner *KeyFieldBasedPartitioner) WithSeparator(separator string) *KeyFieldBasedPartitioner {
+	partitioner.fields.WithSeparator(separator)
+	return partitioner
+}
+
+func (partitioner *KeyFieldBasedPartitioner) Partition(key []byte, numPartitions int) int {
+	if partitioner.empty {
+		return hashPartition(StringHashCode(string(key)), numPartitions)
+	}
+	if len(key) == 0 {
+		return 0
+	}
+	fields := partitioner.fields
+	lengths := fields.wordLengths(key)
+	hash := int32(0)
+	for _, spec := range fields.Specs() {
+		start := fields.startOffset(key, lengths, spec)
+		if start < 0 {
+			continue
+		}
+		end := fields.endOffset(key, lengths, spec)
+		for i := start; i <= end; i++ {
+			hash = 31*hash + int32(int8(key[i]))
+		}
+	}
+	return hashPartition(hash, numPartitions)
+}
+
+func NewPartitionerFromJobConf(conf *JobConf) (Partitioner, error) {
+	class := conf.getStringAlias("", "mapreduce.job.partitioner.class", "mapred.partitioner.class")
+	if !strings.HasSuffix(class, ".KeyFieldBasedPartitioner") {
+		return NewHashPartitioner(), nil
+	}
+	partitioner, err := NewKeyFieldBasedPartitioner(conf.getStringAlias("",
+		"mapreduce.partition.keypartitioner.options", "mapred.text.key.partitioner.options"))
+	if err != nil {
+		return nil, err
+	}
+	return partitioner.WithSeparator(conf.getStringAlias("\t",
+		"mapreduce.map.output.key.field.separator", "map.output.key.field.separator")), nil
+}
+
+func PartitionKey[K any](partitioner Partitioner, serializer Serializer[K], key K, numPartitions int) (int, error) {
+	if numPartitions <= 0 {
+		return 0, fmt.Errorf("invalid number of partitions: %v", numPartitions)
+	}
+	data, err := serializer.Serialize(key)
+	if err != nil {
+		return 0, err
+	}
+	return partitioner.Partition(data, numPartitions), nil
+}
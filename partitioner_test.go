@@ -0,0 +1,117 @@
+package hadoop_streaming
+
+import "testing"
+
+func TestTextHashCode(t *testing.T) {
+	tests := []struct {
+		key  string
+		hash int32
+	}{
+		{"", 1},
+		{"a", 128},
+		{"hello", 127791473},
+		{"é", -1017},
+	}
+	for _, test := range tests {
+		if hash := TextHashCode([]byte(test.key)); hash != test.hash {
+			t.Errorf("TextHashCode(%q) = %v, want %v", test.key, hash, test.hash)
+		}
+	}
+}
+
+func TestStringHashCode(t *testing.T) {
+	tests := []struct {
+		key  string
+		hash int32
+	}{
+		{"", 0},
+		{"hello", 99162322},
+		{"Aa", 2112},
+		{"BB", 2112},
+		{"é", 233},
+		{"😀", 1772899},
+		{"polygenelubricants", -2147483648},
+	}
+	for _, test := range tests {
+		if hash := StringHashCode(test.key); hash != test.hash {
+			t.Errorf("StringHashCode(%q) = %v, want %v", test.key, hash, test.hash)
+		}
+	}
+}
+
+func TestHashPartitioner(t *testing.T) {
+	partitioner := NewHashPartitioner()
+	if partition := partitioner.Partition([]byte("hello"), 10); partition != 3 {
+		t.Errorf("partition = %v, want 3", partition)
+	}
+	if partition := partitioner.Partition([]byte("é"), 10); partition != 1 {
+		t.Errorf("partition = %v, want 1", partition)
+	}
+}
+
+func TestKeyFieldBasedPartitioner(t *testing.T) {
+	tests := []struct {
+		options       string
+		key           string
+		numPartitions int
+		partition     int
+	}{
+		{"", "hello", 10, 2},
+		{"-k2,2", "a\tbc", 7, 1},
+		{"-k1.2,1.3", "xyz\tq", 5, 3},
+		{"-k1,1 -k2,2", "a\tb", 4, 1},
+		{"-k3,3", "a\tb", 4, 0},
+		{"-k1,1", "", 4, 0},
+	}
+	for _, test := range tests {
+		partitioner, err := NewKeyFieldBasedPartitioner(test.options)
+		if err != nil {
+			t.Fatalf("NewKeyFieldBasedPartitioner(%q): %v", test.options, err)
+		}
+		if partition := partitioner.Partition([]byte(test.key), test.numPartitions); partition != test.partition {
+			t.Errorf("%q: Partition(%q, %v) = %v, want %v",
+				test.options, test.key, test.numPartitions, partition, test.partition)
+		}
+	}
+}
+
+func TestNewPartitionerFromJobConf(t *testing.T) {
+	tests := []struct {
+		conf      map[string]string
+		keyField  bool
+		partition int
+	}{
+		{map[string]string{}, false, 3},
+		{map[string]string{
+			"mapreduce.job.partitioner.class":            "org.apache.hadoop.mapreduce.lib.partition.KeyFieldBasedPartitioner",
+			"mapreduce.partition.keypartitioner.options": "-k2,2",
+		}, true, 1},
+		{map[string]string{
+			"mapred.partitioner.class":            "org.apache.hadoop.mapred.lib.KeyFieldBasedPartitioner",
+			"mapred.text.key.partitioner.options": "-k2,2",
+			"map.output.key.field.separator":      ".",
+		}, true, 1},
+	}
+	keys := map[bool]string{false: "hello", true: "a\tbc"}
+	for i, test := range tests {
+		conf := NewJobConfFromMap(test.conf)
+		partitioner, err := NewPartitionerFromJobConf(conf)
+		if err != nil {
+			t.Fatalf("%v: %v", i, err)
+		}
+		if _, ok := partitioner.(*KeyFieldBasedPartitioner); ok != test.keyField {
+			t.Errorf("%v: got %T", i, partitioner)
+		}
+		key := keys[test.keyField]
+		if _, ok := test.conf["map.output.key.field.separator"]; ok {
+			key = "a.bc"
+		}
+		numPartitions := 10
+		if test.keyField {
+			numPartitions = 7
+		}
+		if partition := partitioner.Partition([]byte(key), numPartitions); partition != test.partition {
+			t.Errorf("%v: partition = %v, want %v", i, partition, test.partition)
+		}
+	}
+}
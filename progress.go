@@ -0,0 +1,50 @@
+package hadoop_streaming
+
+import (
+	"fmt"
+	"time"
+)
+
+type Progress struct {
+	BytesRead  int64
+	BytesTotal int64
+	Fraction   float64
+	Elapsed    time.Duration
+	ETA        time.Duration
+}
+
+func NewProgress(bytesRead, bytesTotal int64, elapsed time.Duration) Progress {
+	progress := Progress{
+		BytesRead:  bytesRead,
+		BytesTotal: bytesTotal,
+		Elapsed:    elapsed,
+		ETA:        -1,
+	}
+	if bytesTotal <= 0 {
+		progress.Fraction = -1
+		return progress
+	}
+	progress.Fraction = float64(bytesRead) / float64(bytesTotal)
+	if progress.Fraction > 1 {
+		progress.Fraction = 1
+	}
+	if progress.Fraction > 0 {
+		progress.ETA = time.Duration(float64(elapsed) * (1 - progress.Fraction) / progress.Fraction)
+	}
+	return progress
+}
+
+func (progress Progress) Known() bool {
+	return progress.BytesTotal > 0
+}
+
+func (progress Progress) String() string {
+	if !progress.Known() {
+		return fmt.Sprintf("read=%v", progress.BytesRead)
+	}
+	msg := fmt.Sprintf("progress=%.1f%%", progress.Fraction*100)
+	if progress.ETA >= 0 {
+		msg += fmt.Sprintf(" eta=%v", progress.ETA.Round(time.Second))
+	}
+	return msg
+}
@@ -4,7 +4,7 @@ import (
 	"io"
 )
 
-type Reducer[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any] interface {
+type Reducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] interface {
 	Setup(ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
 	Reduce(key KEYIN, values Iterator[VALUEIN],
 		ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
@@ -12,10 +12,10 @@ type Reducer[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any] interface {
 	FallbackReadError(err error, ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
 }
 
-type DefaultReducer[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any] struct {
+type DefaultReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] struct {
 }
 
-func NewDefaultReducer[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any]() *DefaultReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+func NewDefaultReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT any]() *DefaultReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
 	return &DefaultReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT]{}
 }
 
@@ -44,34 +44,92 @@ func (reducer *DefaultReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) NewContext(
 	return NewReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT](r, w)
 }
 
-func RunReducer[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any](
+type KeyStartHook[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] interface {
+	OnKeyStart(key KEYIN, ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
+}
+
+// KeyEndHook is called after Reduce once the remaining values of the key
+// have been skipped, so it always marks a real key boundary.
+type KeyEndHook[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] interface {
+	OnKeyEnd(key KEYIN, ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error
+}
+
+func RunReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
 	reducer Reducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT],
 	ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
 	if err := ctx.Check(); err != nil {
 		return err
 	}
+	ctx.startHeartbeat()
+	defer ctx.stopHeartbeat()
+	startHook, _ := reducer.(KeyStartHook[KEYIN, VALUEIN, KEYOUT, VALUEOUT])
+	endHook, _ := reducer.(KeyEndHook[KEYIN, VALUEIN, KEYOUT, VALUEOUT])
 	err := reducer.Setup(ctx)
 	if err != nil {
 		return err
 	}
 	for {
+		if ctx.Stopped() {
+			err = ErrStopped
+			break
+		}
 		var ok bool
 		ok, err = ctx.NextKeyValue()
-		if err != nil {
+		if err != nil && err != ErrStopped {
+			readErr := err
 			err = reducer.FallbackReadError(err, ctx)
 			if err == nil {
-				ctx.Reset()
+				ctx.skipRecord(readErr)
 				continue
 			}
 		}
 		if !ok {
 			break
 		}
-		err = reducer.Reduce(ctx.GetCurrentKey(), ctx.GetValues(), ctx)
+		ctx.stats.keyGroups.Add(1)
+		key, values := ctx.GetCurrentKey(), ctx.GetValues()
+		err = ctx.call(key, func() error {
+			if startHook != nil {
+				if err := startHook.OnKeyStart(key, ctx); err != nil {
+					return err
+				}
+			}
+			if err := reducer.Reduce(key, values, ctx); err != nil {
+				return err
+			}
+			if endHook != nil {
+				for values.HasNext() {
+					values.Next()
+				}
+				return endHook.OnKeyEnd(key, ctx)
+			}
+			return nil
+		})
+		_, panicked := err.(*PanicError)
+		if panicked {
+			for values.HasNext() {
+				values.Next()
+			}
+		}
+		ctx.stats.updateMaxValuesPerKey(ctx.groupValues)
+		if err2 := ctx.closeSpills(); err == nil {
+			err = err2
+		}
 		if err != nil {
+			if panicked {
+				err = reducer.FallbackReadError(err, ctx)
+				if err == nil {
+					// Every value of the dropped group counts as skipped.
+					ctx.stats.skippedRecords.Add(ctx.groupValues)
+					continue
+				}
+			}
 			break
 		}
 	}
+	if err != nil && err != ErrStopped {
+		ctx.Fail(err)
+	}
 	err2 := reducer.Cleanup(ctx)
 	return MergeErrors(err, err2)
 }
@@ -1,16 +1,143 @@
 package hadoop_streaming
 
-import "io"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+type SortCheckMode int
+
+const (
+	SORT_CHECK_NONE SortCheckMode = iota
+	SORT_CHECK_COUNT
+	// SORT_CHECK_ERROR returns an *OrderError from NextKeyValue. If
+	// FallbackReadError ignores it, the record is kept and starts a new group.
+	SORT_CHECK_ERROR
+)
+
+type OrderError struct {
+	Line     []byte
+	Previous []byte
+}
+
+func (oe *OrderError) Error() string {
+	return fmt.Sprintf("reducer input is not sorted: %q after %q", oe.Line, oe.Previous)
+}
 
 type Iterator[T any] interface {
 	HasNext() bool
 	Next() T
 }
 
-type ReducerIterator[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any] struct {
-	key   KEYIN
-	value *VALUEIN
-	ctx   *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]
+// KeyEquality decides whether two consecutive records belong to the same
+// group. The raw key bytes are passed along for strategies that ignore the
+// deserialized keys.
+type KeyEquality[KEYIN any] func(a, b KEYIN, rawA, rawB []byte) bool
+
+func RawKeyEquality[KEYIN any](a, b KEYIN, rawA, rawB []byte) bool {
+	return bytes.Equal(rawA, rawB)
+}
+
+func KeyEqualityFunc[KEYIN any](equal func(a, b KEYIN) bool) KeyEquality[KEYIN] {
+	return func(a, b KEYIN, rawA, rawB []byte) bool {
+		return equal(a, b)
+	}
+}
+
+func ComparableKeyEquality[KEYIN comparable]() KeyEquality[KEYIN] {
+	return func(a, b KEYIN, rawA, rawB []byte) bool {
+		return a == b
+	}
+}
+
+func builtinKeyEquality[KEYIN any]() (KeyEquality[KEYIN], bool) {
+	var equality any
+	var key KEYIN
+	switch any(key).(type) {
+	case string:
+		equality = ComparableKeyEquality[string]()
+	case int:
+		equality = ComparableKeyEquality[int]()
+	case int8:
+		equality = ComparableKeyEquality[int8]()
+	case int16:
+		equality = ComparableKeyEquality[int16]()
+	case int32:
+		equality = ComparableKeyEquality[int32]()
+	case int64:
+		equality = ComparableKeyEquality[int64]()
+	case uint:
+		equality = ComparableKeyEquality[uint]()
+	case uint8:
+		equality = ComparableKeyEquality[uint8]()
+	case uint16:
+		equality = ComparableKeyEquality[uint16]()
+	case uint32:
+		equality = ComparableKeyEquality[uint32]()
+	case uint64:
+		equality = ComparableKeyEquality[uint64]()
+	case float32:
+		equality = ComparableKeyEquality[float32]()
+	case float64:
+		equality = ComparableKeyEquality[float64]()
+	case bool:
+		equality = ComparableKeyEquality[bool]()
+	case NoneKey:
+		equality = ComparableKeyEquality[NoneKey]()
+	default:
+		return nil, false
+	}
+	return equality.(KeyEquality[KEYIN]), true
+}
+
+// DefaultKeyEquality uses the Equal method of KEYIN if it has one, == if
+// KEYIN is comparable without interfaces and the raw key bytes otherwise. Other comparable key
+// types compare through interfaces; ComparableKeyEquality avoids that.
+func DefaultKeyEquality[KEYIN any]() KeyEquality[KEYIN] {
+	var key KEYIN
+	if _, ok := any(key).(interface{ Equal(KEYIN) bool }); ok {
+		return func(a, b KEYIN, rawA, rawB []byte) bool {
+			return any(a).(interface{ Equal(KEYIN) bool }).Equal(b)
+		}
+	}
+	if equality, ok := builtinKeyEquality[KEYIN](); ok {
+		return equality
+	}
+	if strictlyComparable(reflect.TypeOf((*KEYIN)(nil)).Elem()) {
+		return func(a, b KEYIN, rawA, rawB []byte) bool {
+			return any(a) == any(b)
+		}
+	}
+	return RawKeyEquality[KEYIN]
+}
+
+// strictlyComparable reports whether == never panics on values of t, which is
+// not the case for interfaces holding slices or maps.
+func strictlyComparable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface:
+		return false
+	case reflect.Array:
+		return strictlyComparable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !strictlyComparable(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return t.Comparable()
+}
+
+type ReducerIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] struct {
+	key    KEYIN
+	rawKey []byte
+	value  *VALUEIN
+	ctx    *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]
 }
 
 func (iterator *ReducerIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) HasNext() bool {
@@ -18,18 +145,22 @@ func (iterator *ReducerIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) HasNext() boo
 		return true
 	}
 	ctx := iterator.ctx
+	if ctx.Stopped() {
+		return false
+	}
 	key, value, err := ctx.readKeyValue()
 	if err != nil {
 		ctx.err = err
 		return false
 	}
 	valuePtr := &value
-	if iterator.key != key {
+	if !ctx.keyEqual(iterator.key, key, iterator.rawKey, ctx.rawKey()) {
 		ctx.key = key
 		ctx.value = valuePtr
 		return false
 	}
 	iterator.value = valuePtr
+	ctx.groupValues++
 	return true
 }
 
@@ -39,19 +170,105 @@ func (iterator *ReducerIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Next() VALUEI
 	return value
 }
 
-type ReducerContext[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any] struct {
+type ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] struct {
 	*Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT]
-	value *VALUEIN
-	err   error
+	value         *VALUEIN
+	keyEqual      KeyEquality[KEYIN]
+	values        Iterator[VALUEIN]
+	spills        []*SpillingIterator[VALUEIN]
+	err           error
+	groupValues   int64
+	sortCheck     SortCheckMode
+	keyComparator Comparator
+	prevLine      []byte
+	lineBuffer    []byte
 }
 
-func NewReducerContext[KEYIN comparable, VALUEIN, KEYOUT, VALUEOUT any](
+func NewReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
 	r io.Reader, w io.Writer) *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
 	return &ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]{
-		Context: NewContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT](r, w),
+		Context:  NewContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT](r, w),
+		keyEqual: DefaultKeyEquality[KEYIN](),
 	}
 }
 
+func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithKeyEquality(
+	equal KeyEquality[KEYIN]) *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.keyEqual = equal
+	return ctx
+}
+
+func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) rawKey() []byte {
+	key, _, _ := bytes.Cut(ctx.raw, []byte{'\t'})
+	return key
+}
+
+func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithSortCheck(
+	mode SortCheckMode) *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.sortCheck = mode
+	return ctx
+}
+
+// WithKeyComparator sets the comparator that the sort check applies to whole
+// input lines. It defaults to the job's output key comparator.
+func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithKeyComparator(
+	comparator Comparator) *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	ctx.keyComparator = comparator
+	return ctx
+}
+
+func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Check() error {
+	if err := ctx.Context.Check(); err != nil {
+		return err
+	}
+	if ctx.sortCheck != SORT_CHECK_NONE && ctx.keyComparator == nil {
+		comparator, err := NewComparatorFromJobConf(ctx.jobConf)
+		if err != nil {
+			return err
+		}
+		ctx.keyComparator = comparator.CompareLines
+	}
+	return nil
+}
+
+// checkOrder compares the first line of each key group with the previous one
+// the way the shuffle sorted them, so it only keeps one line in memory.
+func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) checkOrder() error {
+	if ctx.sortCheck == SORT_CHECK_NONE || ctx.noKeyIn {
+		return nil
+	}
+	if ctx.keyComparator == nil {
+		if err := ctx.Check(); err != nil {
+			return err
+		}
+	}
+	prevLine := ctx.prevLine
+	ctx.prevLine = append(ctx.lineBuffer[:0], ctx.raw...)
+	ctx.lineBuffer = prevLine
+	if prevLine == nil || ctx.keyComparator(prevLine, ctx.prevLine) <= 0 {
+		return nil
+	}
+	ctx.stats.outOfOrderKeys.Add(1)
+	if ctx.sortCheck == SORT_CHECK_ERROR {
+		return &OrderError{
+			Line:     append([]byte(nil), ctx.prevLine...),
+			Previous: append([]byte(nil), prevLine...),
+		}
+	}
+	return nil
+}
+
+// skipRecord drops the record that failed to read. A record that is only out
+// of order is kept.
+func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) skipRecord(err error) {
+	var orderErr *OrderError
+	if errors.As(err, &orderErr) {
+		return
+	}
+	ctx.stats.skippedRecords.Add(1)
+	ctx.Reset()
+}
+
 func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Reset() {
 	ctx.err = nil
 	ctx.value = nil
@@ -59,30 +276,62 @@ func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Reset() {
 
 func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) NextKeyValue() (bool, error) {
 	if ctx.err != nil {
-		return false, nil
-	}
-	if ctx.value != nil {
-		return true, nil
-	}
-	key, value, err := ctx.readKeyValue()
-	if err != nil {
-		if err == io.EOF {
+		if ctx.err == io.EOF {
 			return false, nil
 		}
+		return false, ctx.err
+	}
+	if ctx.value == nil {
+		key, value, err := ctx.readKeyValue()
+		if err != nil {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, err
+		}
+		ctx.key = key
+		ctx.value = &value
+	}
+	if err := ctx.checkOrder(); err != nil {
 		return false, err
 	}
-	ctx.key = key
-	ctx.value = &value
-
 	return true, nil
 }
 
 func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetValues() Iterator[VALUEIN] {
 	iterator := &ReducerIterator[KEYIN, VALUEIN, KEYOUT, VALUEOUT]{
-		key:   ctx.key,
-		value: ctx.value,
-		ctx:   ctx,
+		key:    ctx.key,
+		rawKey: append([]byte(nil), ctx.rawKey()...),
+		value:  ctx.value,
+		ctx:    ctx,
+	}
+	ctx.groupValues = 0
+	if ctx.value != nil {
+		ctx.groupValues = 1
 	}
 	ctx.value = nil
+	ctx.values = iterator
 	return iterator
 }
+
+// GetReiterableValues wraps the values of the current key in a rewindable
+// iterator that spills to disk past maxBytes. It is closed after Reduce.
+func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) GetReiterableValues(
+	maxBytes int64) *SpillingIterator[VALUEIN] {
+	iterator := NewSpillingIterator[VALUEIN](ctx.values, ctx.valueInSerializer, maxBytes)
+	ctx.values = iterator
+	ctx.spills = append(ctx.spills, iterator)
+	return iterator
+}
+
+// closeSpills also returns the errors that ended a spill's iteration early, so
+// that a group whose values were cut short fails.
+func (ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) closeSpills() error {
+	var errs []error
+	for _, spill := range ctx.spills {
+		errs = append(errs, spill.Err(), spill.Close())
+	}
+	ctx.spills = nil
+	ctx.values = nil
+	return MergeErrors(errs...)
+}
@@ -0,0 +1,138 @@
+package hadoop_streaming
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sumReducer panics on the key boom and skips whatever fails to read.
+type sumReducer struct {
+	*DefaultReducer[string, int, string, int]
+}
+
+func (reducer *sumReducer) Reduce(key string, values Iterator[int],
+	ctx *ReducerContext[string, int, string, int]) error {
+	sum := 0
+	for values.HasNext() {
+		sum += values.Next()
+		if key == "boom" {
+			panic("boom")
+		}
+	}
+	return ctx.Write(key, sum)
+}
+
+func (reducer *sumReducer) FallbackReadError(err error,
+	ctx *ReducerContext[string, int, string, int]) error {
+	return nil
+}
+
+func TestReducerRecoverPanic(t *testing.T) {
+	var out strings.Builder
+	ctx := NewReducerContext[string, int, string, int](
+		strings.NewReader("a\t1\nboom\t1\nboom\t2\nboom\t3\nc\t1\nc\t2\n"), &out)
+	ctx.WithRecoverPanic(true)
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	if err := RunReducer[string, int, string, int](&sumReducer{}, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\t1\nc\t3\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if skipped := ctx.stats.skippedRecords.Load(); skipped != 3 {
+		t.Errorf("skipped = %v, want 3", skipped)
+	}
+}
+
+func runSortCheck(input string, mode SortCheckMode, conf map[string]string,
+	reducer Reducer[string, int, string, int]) (string, int64, error) {
+	var out strings.Builder
+	ctx := NewReducerContext[string, int, string, int](strings.NewReader(input), &out).WithSortCheck(mode)
+	ctx.WithJobConf(NewJobConfFromMap(conf))
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	err := RunReducer[string, int, string, int](reducer, ctx)
+	err = MergeErrors(err, ctx.Close())
+	return out.String(), ctx.stats.outOfOrderKeys.Load(), err
+}
+
+func TestSortCheck(t *testing.T) {
+	numeric := map[string]string{
+		"mapreduce.job.output.key.comparator.class": "org.apache.hadoop.mapreduce.lib.partition.KeyFieldBasedComparator",
+		"mapreduce.partition.keycomparator.options": "-k1,1n",
+	}
+	tests := []struct {
+		input      string
+		mode       SortCheckMode
+		conf       map[string]string
+		outOfOrder int64
+		want       string
+	}{
+		{"a\t1\nb\t2\nb\t3\n", SORT_CHECK_COUNT, nil, 0, "a\t1\nb\t5\n"},
+		{"b\t1\na\t2\nb\t3\n", SORT_CHECK_COUNT, nil, 1, "b\t1\na\t2\nb\t3\n"},
+		{"b\t1\na\t2\nb\t3\n", SORT_CHECK_ERROR, nil, 1, "b\t1\na\t2\nb\t3\n"},
+		{"9\t1\n10\t2\n", SORT_CHECK_ERROR, numeric, 0, "9\t1\n10\t2\n"},
+		{"9\t1\n10\t2\n", SORT_CHECK_COUNT, nil, 1, "9\t1\n10\t2\n"},
+	}
+	for i, test := range tests {
+		out, outOfOrder, err := runSortCheck(test.input, test.mode, test.conf, &sumReducer{})
+		if err != nil {
+			t.Fatalf("%v: %v", i, err)
+		}
+		if outOfOrder != test.outOfOrder || out != test.want {
+			t.Errorf("%v: out of order = %v, output = %q, want %v, %q", i, outOfOrder, out, test.outOfOrder, test.want)
+		}
+	}
+}
+
+func TestSortCheckError(t *testing.T) {
+	out, _, err := runSortCheck("b\t1\na\t2\nb\t3\n", SORT_CHECK_ERROR, nil,
+		NewDefaultReducer[string, int, string, int]())
+	var orderErr *OrderError
+	if !errors.As(err, &orderErr) || string(orderErr.Line) != "a\t2" || string(orderErr.Previous) != "b\t1" {
+		t.Errorf("err = %v, want an order error for a after b", err)
+	}
+	if out != "" {
+		t.Errorf("output = %q", out)
+	}
+}
+
+type hookReducer struct {
+	*DefaultReducer[string, int, string, int]
+	events []string
+}
+
+func (reducer *hookReducer) OnKeyStart(key string, ctx *ReducerContext[string, int, string, int]) error {
+	reducer.events = append(reducer.events, "start "+key)
+	return nil
+}
+
+func (reducer *hookReducer) Reduce(key string, values Iterator[int],
+	ctx *ReducerContext[string, int, string, int]) error {
+	reducer.events = append(reducer.events, fmt.Sprint("reduce ", key, " ", values.Next()))
+	return nil
+}
+
+func (reducer *hookReducer) OnKeyEnd(key string, ctx *ReducerContext[string, int, string, int]) error {
+	reducer.events = append(reducer.events, fmt.Sprint("end ", key, " after ", ctx.groupValues))
+	return nil
+}
+
+func TestReducerHooks(t *testing.T) {
+	ctx := NewReducerContext[string, int, string, int](
+		strings.NewReader("a\t1\na\t2\na\t3\nb\t4\n"), &strings.Builder{})
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	reducer := &hookReducer{}
+	if err := RunReducer[string, int, string, int](reducer, ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start a", "reduce a 1", "end a after 3", "start b", "reduce b 4", "end b after 1"}
+	if !reflect.DeepEqual(reducer.events, want) {
+		t.Errorf("events = %q, want %q", reducer.events, want)
+	}
+}
@@ -0,0 +1,39 @@
+package hadoop_streaming
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+type Reporter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func NewReporter(writer io.Writer) *Reporter {
+	return &Reporter{
+		writer: writer,
+	}
+}
+
+func (reporter *Reporter) Counter(group, counter string, amount int64) error {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	_, err := fmt.Fprintf(reporter.writer, "reporter:counter:%v,%v,%v\n", group, counter, amount)
+	return err
+}
+
+func (reporter *Reporter) Status(msg string) error {
+	msg = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, msg)
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	_, err := fmt.Fprintf(reporter.writer, "reporter:status:%v\n", msg)
+	return err
+}
@@ -0,0 +1,106 @@
+package hadoop_streaming
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const ROUTE_SEPARATOR = '/'
+
+type RoutedKey[R, K any] struct {
+	Route R
+	Key   K
+}
+
+func NewRoutedKey[R, K any](route R, key K) RoutedKey[R, K] {
+	return RoutedKey[R, K]{Route: route, Key: key}
+}
+
+func (key RoutedKey[R, K]) newSerializer() any {
+	return NewRoutedKeySerializer[R, K]()
+}
+
+func ValidateRoute(route []byte) error {
+	if len(route) == 0 {
+		return fmt.Errorf("route is empty")
+	}
+	if bytes.ContainsAny(route, "\t\r\n") {
+		return fmt.Errorf("route contains control characters: %q", route)
+	}
+	for _, segment := range bytes.Split(route, []byte{ROUTE_SEPARATOR}) {
+		if len(segment) == 0 || string(segment) == "." || string(segment) == ".." {
+			return fmt.Errorf("invalid route: %q", route)
+		}
+	}
+	return nil
+}
+
+func SplitRoutedKey(data []byte) ([]byte, []byte, error) {
+	i := bytes.LastIndexByte(data, ROUTE_SEPARATOR)
+	if i < 0 {
+		return nil, nil, fmt.Errorf("key has no route: %q", data)
+	}
+	route, key := data[:i], data[i+1:]
+	if err := ValidateRoute(route); err != nil {
+		return nil, nil, err
+	}
+	return route, key, nil
+}
+
+type RoutedKeySerializer[R, K any] struct {
+	routeSerializer Serializer[R]
+	keySerializer   Serializer[K]
+}
+
+func NewRoutedKeySerializer[R, K any]() *RoutedKeySerializer[R, K] {
+	return &RoutedKeySerializer[R, K]{
+		routeSerializer: NewSerializer[R](),
+		keySerializer:   NewSerializer[K](),
+	}
+}
+
+func (s *RoutedKeySerializer[R, K]) WithRouteSerializer(serializer Serializer[R]) *RoutedKeySerializer[R, K] {
+	s.routeSerializer = serializer
+	return s
+}
+
+func (s *RoutedKeySerializer[R, K]) WithKeySerializer(serializer Serializer[K]) *RoutedKeySerializer[R, K] {
+	s.keySerializer = serializer
+	return s
+}
+
+func (s *RoutedKeySerializer[R, K]) Serialize(from RoutedKey[R, K]) ([]byte, error) {
+	route, err := s.routeSerializer.Serialize(from.Route)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateRoute(route); err != nil {
+		return nil, err
+	}
+	key, err := s.keySerializer.Serialize(from.Key)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.ContainsAny(key, "/\t\r\n") {
+		return nil, fmt.Errorf("routed key contains separator: %q", key)
+	}
+	data := make([]byte, 0, len(route)+len(key)+1)
+	data = append(data, route...)
+	data = append(data, ROUTE_SEPARATOR)
+	return append(data, key...), nil
+}
+
+func (s *RoutedKeySerializer[R, K]) Deserialize(to []byte) (RoutedKey[R, K], error) {
+	var routed RoutedKey[R, K]
+	routeBytes, keyBytes, err := SplitRoutedKey(to)
+	if err != nil {
+		return routed, err
+	}
+	if routed.Route, err = s.routeSerializer.Deserialize(routeBytes); err != nil {
+		return routed, err
+	}
+	if routed.Key, err = s.keySerializer.Deserialize(keyBytes); err != nil {
+		return routed, err
+	}
+	return routed, nil
+}
@@ -0,0 +1,202 @@
+package hadoop_streaming
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	EXIT_SUCCESS     = 0
+	EXIT_FAILURE     = 1
+	EXIT_PANIC       = 2
+	EXIT_BROKEN_PIPE = 128 + int(syscall.SIGPIPE)
+	EXIT_TERMINATED  = 128 + int(syscall.SIGTERM)
+)
+
+type MapperContextFactory[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] interface {
+	NewContext(r io.Reader, w io.Writer) *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]
+}
+
+type ReducerContextFactory[KEYIN, VALUEIN, KEYOUT, VALUEOUT any] interface {
+	NewContext(r io.Reader, w io.Writer) *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]
+}
+
+type contextFactory[CTX any] interface {
+	NewContext(r io.Reader, w io.Writer) CTX
+}
+
+func execTask[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
+	ctx *Context[KEYIN, VALUEIN, KEYOUT, VALUEOUT], run func() error) (err error) {
+	release := handleSignals(ctx.Stop, ctx.abort, ctx.gracePeriod)
+	defer release()
+	ctx.interruptReads()
+	defer func() {
+		if r := recover(); r != nil {
+			err = MergeErrors(err, NewPanicError(r, ctx.GetCurrentKey(), ctx.GetCurrentRaw()))
+		}
+		err = MergeErrors(err, ctx.Close())
+	}()
+	return run()
+}
+
+func newRunner[CTX any](task any, newContext func(r io.Reader, w io.Writer) CTX, exec func(ctx CTX) error) Runner {
+	return func() error {
+		signal.Ignore(syscall.SIGPIPE)
+		if factory, ok := task.(contextFactory[CTX]); ok {
+			newContext = factory.NewContext
+		}
+		return exec(newContext(os.Stdin, os.Stdout))
+	}
+}
+
+func ExecMapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
+	mapper Mapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT],
+	ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+	return execTask(ctx.Context, func() error {
+		return RunMapper(mapper, ctx)
+	})
+}
+
+func ExecReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
+	reducer Reducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT],
+	ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+	return execTask(ctx.Context, func() error {
+		return RunReducer(reducer, ctx)
+	})
+}
+
+func ExecPartitionReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
+	reducer PartitionReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT],
+	ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+	return execTask(ctx.Context, func() error {
+		return RunPartitionReducer(reducer, ctx)
+	})
+}
+
+func NewMapperRunner[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
+	mapper Mapper[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Runner {
+	return newRunner(mapper, NewMapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT],
+		func(ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+			return ExecMapper(mapper, ctx)
+		})
+}
+
+func NewReducerRunner[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
+	reducer Reducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Runner {
+	return newRunner(reducer, NewReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT],
+		func(ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+			return ExecReducer(reducer, ctx)
+		})
+}
+
+func NewPartitionReducerRunner[KEYIN, VALUEIN, KEYOUT, VALUEOUT any](
+	reducer PartitionReducer[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) Runner {
+	return newRunner(reducer, NewReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT],
+		func(ctx *ReducerContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) error {
+			return ExecPartitionReducer(reducer, ctx)
+		})
+}
+
+func handleSignals(stop func(), abort func() error, gracePeriod time.Duration) func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			stop()
+			timer := time.NewTimer(gracePeriod)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+				fmt.Fprintf(os.Stderr, "received %v, grace period %v exceeded\n", sig, gracePeriod)
+				if err := abort(); err != nil {
+					fmt.Fprintf(os.Stderr, "abort: %v\n", err)
+				}
+				os.Exit(EXIT_TERMINATED)
+			case <-done:
+			}
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+func ExitCode(err error) int {
+	if err == nil {
+		return EXIT_SUCCESS
+	}
+	if errors.Is(err, syscall.EPIPE) {
+		return EXIT_BROKEN_PIPE
+	}
+	if errors.Is(err, ErrStopped) {
+		return EXIT_TERMINATED
+	}
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		return EXIT_PANIC
+	}
+	return EXIT_FAILURE
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+type interruptibleReader struct {
+	reader  io.Reader
+	done    <-chan struct{}
+	results chan readResult
+	pending bool
+	buf     []byte
+	rest    []byte
+	err     error
+}
+
+func newInterruptibleReader(r io.Reader, done <-chan struct{}) *interruptibleReader {
+	return &interruptibleReader{
+		reader:  r,
+		done:    done,
+		results: make(chan readResult, 1),
+	}
+}
+
+func (r *interruptibleReader) Read(p []byte) (int, error) {
+	if len(r.rest) == 0 && r.err == nil {
+		if !r.pending {
+			if cap(r.buf) < len(p) {
+				r.buf = make([]byte, len(p))
+			}
+			buf := r.buf[:len(p)]
+			r.pending = true
+			go func() {
+				n, err := r.reader.Read(buf)
+				r.results <- readResult{n: n, err: err}
+			}()
+		}
+		select {
+		case result := <-r.results:
+			r.pending = false
+			r.rest, r.err = r.buf[:result.n], result.err
+		case <-r.done:
+			return 0, ErrStopped
+		}
+	}
+	n := copy(p, r.rest)
+	r.rest = r.rest[n:]
+	if len(r.rest) == 0 && r.err != nil {
+		err := r.err
+		r.err = nil
+		return n, err
+	}
+	return n, nil
+}
@@ -0,0 +1,194 @@
+package hadoop_streaming
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+const SALT_SEPARATOR = '#'
+
+type Salter struct {
+	numSalts    int
+	separator   byte
+	offset      int
+	hotKeys     map[string]struct{}
+	next        map[string]int
+	detector    *HeavyHitters
+	fraction    float64
+	minRecords  int64
+	saltedCount Counter
+}
+
+func NewSalter(numSalts int) *Salter {
+	return &Salter{
+		numSalts:  numSalts,
+		separator: SALT_SEPARATOR,
+		offset:    -1,
+		hotKeys:   map[string]struct{}{},
+		next:      map[string]int{},
+	}
+}
+
+func (salter *Salter) WithHotKeys(keys ...string) *Salter {
+	for _, key := range keys {
+		salter.hotKeys[key] = struct{}{}
+	}
+	return salter
+}
+
+func (salter *Salter) WithSeparator(separator byte) *Salter {
+	salter.separator = separator
+	return salter
+}
+
+// WithOffset sets the first salt of each key. Unless set, WithSalter uses the
+// task partition so that mappers spread a hot key differently.
+func (salter *Salter) WithOffset(offset int) *Salter {
+	salter.offset = offset
+	return salter
+}
+
+func (salter *Salter) WithDetection(capacity int, fraction float64, minRecords int64) *Salter {
+	salter.detector = NewHeavyHitters(capacity)
+	salter.fraction = fraction
+	salter.minRecords = minRecords
+	return salter
+}
+
+func (salter *Salter) WithSaltedCounter(counter Counter) *Salter {
+	salter.saltedCount = counter
+	return salter
+}
+
+func (salter *Salter) Detector() *HeavyHitters {
+	return salter.detector
+}
+
+func (salter *Salter) IsHot(key []byte) bool {
+	if _, ok := salter.hotKeys[string(key)]; ok {
+		return true
+	}
+	if salter.detector == nil || salter.detector.Total() < salter.minRecords {
+		return false
+	}
+	return salter.detector.IsHeavy(key, salter.fraction)
+}
+
+func (salter *Salter) Salt(key []byte) []byte {
+	if salter.detector != nil {
+		salter.detector.Add(key)
+	}
+	if salter.numSalts <= 1 || !salter.IsHot(key) {
+		return key
+	}
+	n := salter.next[string(key)]
+	salter.next[string(key)] = n + 1
+	salt := (n + max(salter.offset, 0)) % salter.numSalts
+	if salter.saltedCount != nil {
+		salter.saltedCount.Increment(1)
+	}
+	salted := make([]byte, 0, len(key)+4)
+	salted = append(salted, key...)
+	salted = append(salted, salter.separator)
+	return strconv.AppendInt(salted, int64(salt), 10)
+}
+
+func UnsaltKey(data []byte, separator byte) []byte {
+	i := bytes.LastIndexByte(data, separator)
+	if i < 0 || i == len(data)-1 {
+		return data
+	}
+	for _, c := range data[i+1:] {
+		if !isDigit(c) {
+			return data
+		}
+	}
+	return data[:i]
+}
+
+func (salter *Salter) saltKey(key []byte) ([]byte, error) {
+	if bytes.IndexByte(key, salter.separator) >= 0 {
+		return nil, fmt.Errorf("salted key contains separator: %q", key)
+	}
+	return salter.Salt(key), nil
+}
+
+// UnsaltingSerializer strips salts from keys, for the second aggregation pass.
+type UnsaltingSerializer[T any] struct {
+	serializer Serializer[T]
+	separator  byte
+}
+
+func NewUnsaltingSerializer[T any](serializer Serializer[T]) *UnsaltingSerializer[T] {
+	return &UnsaltingSerializer[T]{
+		serializer: serializer,
+		separator:  SALT_SEPARATOR,
+	}
+}
+
+func (s *UnsaltingSerializer[T]) WithSeparator(separator byte) *UnsaltingSerializer[T] {
+	s.separator = separator
+	return s
+}
+
+func (s *UnsaltingSerializer[T]) Serialize(from T) ([]byte, error) {
+	return s.serializer.Serialize(from)
+}
+
+func (s *UnsaltingSerializer[T]) Deserialize(to []byte) (T, error) {
+	return s.serializer.Deserialize(UnsaltKey(to, s.separator))
+}
+
+// WithSalter salts the serialized key of each Write. GetPartition and other
+// uses of the key serializer see the key unsalted.
+func (ctx *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT]) WithSalter(
+	salter *Salter) *MapperContext[KEYIN, VALUEIN, KEYOUT, VALUEOUT] {
+	if salter.offset < 0 {
+		salter.offset = ctx.jobConf.TaskPartition()
+	}
+	ctx.saltKey = salter.saltKey
+	return ctx
+}
+
+type IdentityMapper[K, V any] struct {
+	*DefaultMapper[K, V, K, V]
+}
+
+func NewIdentityMapper[K, V any]() *IdentityMapper[K, V] {
+	return &IdentityMapper[K, V]{
+		DefaultMapper: NewDefaultMapper[K, V, K, V](),
+	}
+}
+
+func (mapper *IdentityMapper[K, V]) Map(key K, value V, ctx *MapperContext[K, V, K, V]) error {
+	return ctx.Write(key, value)
+}
+
+type MergeFunc[V any] func(a, b V) (V, error)
+
+type MergeReducer[K, V any] struct {
+	*DefaultReducer[K, V, K, V]
+	merge MergeFunc[V]
+}
+
+func NewMergeReducer[K, V any](merge MergeFunc[V]) *MergeReducer[K, V] {
+	return &MergeReducer[K, V]{
+		DefaultReducer: NewDefaultReducer[K, V, K, V](),
+		merge:          merge,
+	}
+}
+
+func (reducer *MergeReducer[K, V]) Reduce(key K, values Iterator[V], ctx *ReducerContext[K, V, K, V]) error {
+	if !values.HasNext() {
+		return nil
+	}
+	merged := values.Next()
+	for values.HasNext() {
+		var err error
+		if merged, err = reducer.merge(merged, values.Next()); err != nil {
+			return err
+		}
+	}
+	return ctx.Write(key, merged)
+}
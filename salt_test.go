@@ -0,0 +1,122 @@
+package hadoop_streaming
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSalt(t *testing.T) {
+	salter := NewSalter(3).WithHotKeys("hot").WithOffset(1)
+	var salted []string
+	for _, key := range []string{"hot", "cold", "hot", "hot", "hot"} {
+		salted = append(salted, string(salter.Salt([]byte(key))))
+	}
+	if want := []string{"hot#1", "cold", "hot#2", "hot#0", "hot#1"}; !reflect.DeepEqual(salted, want) {
+		t.Errorf("salted = %q, want %q", salted, want)
+	}
+	detecting := NewSalter(2).WithDetection(4, 0.5, 4).WithOffset(0)
+	salted = nil
+	for _, key := range []string{"a", "a", "a", "b", "a", "a"} {
+		salted = append(salted, string(detecting.Salt([]byte(key))))
+	}
+	if want := []string{"a", "a", "a", "b", "a#0", "a#1"}; !reflect.DeepEqual(salted, want) {
+		t.Errorf("detected salts = %q, want %q", salted, want)
+	}
+}
+
+func TestUnsaltKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"a#1", "a"},
+		{"a#12", "a"},
+		{"a", "a"},
+		{"a#", "a#"},
+		{"a#x", "a#x"},
+		{"a#b#3", "a#b"},
+	}
+	for _, test := range tests {
+		if got := UnsaltKey([]byte(test.key), SALT_SEPARATOR); string(got) != test.want {
+			t.Errorf("UnsaltKey(%q) = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
+
+func TestHeavyHittersEviction(t *testing.T) {
+	hh := NewHeavyHitters(2)
+	for _, key := range []string{"a", "a", "b", "c"} {
+		hh.Add([]byte(key))
+	}
+	want := []HeavyHitter{{Key: "a", Count: 2}, {Key: "c", Count: 2, Error: 1}}
+	if top := hh.Top(-1); !reflect.DeepEqual(top, want) {
+		t.Errorf("Top = %+v, want %+v", top, want)
+	}
+	if hh.Count([]byte("b")) != 0 || !hh.IsHeavy([]byte("a"), 0.5) || hh.IsHeavy([]byte("c"), 0.5) {
+		t.Errorf("b = %v, a heavy = %v, c heavy = %v",
+			hh.Count([]byte("b")), hh.IsHeavy([]byte("a"), 0.5), hh.IsHeavy([]byte("c"), 0.5))
+	}
+	// a and c tie on count, so the least recently updated one is evicted.
+	hh.Add([]byte("d"))
+	want = []HeavyHitter{{Key: "d", Count: 3, Error: 2}, {Key: "c", Count: 2, Error: 1}}
+	if top := hh.Top(-1); !reflect.DeepEqual(top, want) {
+		t.Errorf("Top after eviction = %+v, want %+v", top, want)
+	}
+	if top := hh.Top(1); len(top) != 1 || top[0].Key != "d" || hh.Total() != 5 {
+		t.Errorf("Top(1) = %+v, Total = %v", top, hh.Total())
+	}
+}
+
+func TestWithSalter(t *testing.T) {
+	tests := []struct {
+		salter *Salter
+		want   string
+	}{
+		{NewSalter(3).WithHotKeys("hot"), "hot#2\t1\nhot#0\t2\ncold\t3\n"},
+		{NewSalter(3).WithHotKeys("hot").WithOffset(0), "hot#0\t1\nhot#1\t2\ncold\t3\n"},
+	}
+	for i, test := range tests {
+		var out strings.Builder
+		ctx := NewMapperContext[string, int, string, int](strings.NewReader(""), &out)
+		ctx.WithJobConf(NewJobConfFromMap(map[string]string{"mapreduce.task.partition": "2"}))
+		ctx.WithSalter(test.salter)
+		for value, key := range []string{"hot", "hot", "cold"} {
+			if _, err := ctx.GetPartition(NewHashPartitioner(), key); err != nil {
+				t.Fatal(err)
+			}
+			if err := ctx.Write(key, value+1); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var serializeErr *SerializeError
+		if err := ctx.Write("a#1", 0); !errors.As(err, &serializeErr) {
+			t.Errorf("%v: writing a key with the separator: %v", i, err)
+		}
+		ctx.GetCounters().WithReporter(&strings.Builder{})
+		if err := ctx.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.want {
+			t.Errorf("%v: output = %q, want %q", i, out.String(), test.want)
+		}
+	}
+}
+
+func TestMergeReducer(t *testing.T) {
+	var out strings.Builder
+	ctx := NewReducerContext[string, int, string, int](strings.NewReader("a#0\t1\na#1\t2\nb\t3\n"), &out)
+	ctx.WithKeyInSerializer(NewUnsaltingSerializer[string](&StringSerializer{}))
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	reducer := NewMergeReducer[string, int](func(a, b int) (int, error) { return a + b, nil })
+	if err := RunReducer[string, int, string, int](reducer, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\t3\nb\t3\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,152 @@
+package hadoop_streaming
+
+import (
+	"math/rand"
+	"time"
+)
+
+type Sampler interface {
+	Sample(key []byte)
+	Samples() [][]byte
+}
+
+type RandomSampler struct {
+	freq       float64
+	numSamples int
+	rand       *rand.Rand
+	samples    [][]byte
+}
+
+func NewRandomSampler(freq float64, numSamples int) *RandomSampler {
+	return &RandomSampler{
+		freq:       freq,
+		numSamples: numSamples,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (sampler *RandomSampler) WithSeed(seed int64) *RandomSampler {
+	sampler.rand = rand.New(rand.NewSource(seed))
+	return sampler
+}
+
+func (sampler *RandomSampler) Sample(key []byte) {
+	if sampler.rand.Float64() > sampler.freq {
+		return
+	}
+	if len(sampler.samples) < sampler.numSamples {
+		sampler.samples = append(sampler.samples, append([]byte(nil), key...))
+		return
+	}
+	sampler.samples[sampler.rand.Intn(sampler.numSamples)] = append([]byte(nil), key...)
+	sampler.freq *= float64(sampler.numSamples-1) / float64(sampler.numSamples)
+}
+
+func (sampler *RandomSampler) Samples() [][]byte {
+	return sampler.samples
+}
+
+type IntervalSampler struct {
+	freq    float64
+	records int64
+	kept    int64
+	samples [][]byte
+}
+
+func NewIntervalSampler(freq float64) *IntervalSampler {
+	return &IntervalSampler{
+		freq: freq,
+	}
+}
+
+func (sampler *IntervalSampler) Sample(key []byte) {
+	sampler.records++
+	if float64(sampler.kept)/float64(sampler.records) < sampler.freq {
+		sampler.kept++
+		sampler.samples = append(sampler.samples, append([]byte(nil), key...))
+	}
+}
+
+func (sampler *IntervalSampler) Samples() [][]byte {
+	return sampler.samples
+}
+
+type ReservoirSampler struct {
+	numSamples int
+	seen       int64
+	rand       *rand.Rand
+	samples    [][]byte
+}
+
+func NewReservoirSampler(numSamples int) *ReservoirSampler {
+	return &ReservoirSampler{
+		numSamples: numSamples,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (sampler *ReservoirSampler) WithSeed(seed int64) *ReservoirSampler {
+	sampler.rand = rand.New(rand.NewSource(seed))
+	return sampler
+}
+
+func (sampler *ReservoirSampler) Sample(key []byte) {
+	sampler.seen++
+	if len(sampler.samples) < sampler.numSamples {
+		sampler.samples = append(sampler.samples, append([]byte(nil), key...))
+		return
+	}
+	if i := sampler.rand.Int63n(sampler.seen); i < int64(sampler.numSamples) {
+		sampler.samples[i] = append([]byte(nil), key...)
+	}
+}
+
+func (sampler *ReservoirSampler) Samples() [][]byte {
+	return sampler.samples
+}
+
+type SamplingMapper[KEYIN, VALUEIN, K any] struct {
+	*DefaultMapper[KEYIN, VALUEIN, NoneKey, string]
+	sampler    Sampler
+	serializer Serializer[K]
+	extract    func(key KEYIN, value VALUEIN) (K, error)
+}
+
+func NewSamplingMapper[KEYIN, VALUEIN, K any](sampler Sampler,
+	extract func(key KEYIN, value VALUEIN) (K, error)) *SamplingMapper[KEYIN, VALUEIN, K] {
+	return &SamplingMapper[KEYIN, VALUEIN, K]{
+		DefaultMapper: NewDefaultMapper[KEYIN, VALUEIN, NoneKey, string](),
+		sampler:       sampler,
+		serializer:    NewSerializer[K](),
+		extract:       extract,
+	}
+}
+
+func (mapper *SamplingMapper[KEYIN, VALUEIN, K]) WithSerializer(serializer Serializer[K]) *SamplingMapper[KEYIN, VALUEIN, K] {
+	mapper.serializer = serializer
+	return mapper
+}
+
+func (mapper *SamplingMapper[KEYIN, VALUEIN, K]) Map(key KEYIN, value VALUEIN,
+	ctx *MapperContext[KEYIN, VALUEIN, NoneKey, string]) error {
+	k, err := mapper.extract(key, value)
+	if err != nil {
+		return err
+	}
+	data, err := mapper.serializer.Serialize(k)
+	if err != nil {
+		return err
+	}
+	mapper.sampler.Sample(data)
+	return nil
+}
+
+func (mapper *SamplingMapper[KEYIN, VALUEIN, K]) Cleanup(ctx *MapperContext[KEYIN, VALUEIN, NoneKey, string]) error {
+	var noneKey NoneKey
+	for _, sample := range mapper.sampler.Samples() {
+		if err := ctx.Write(noneKey, string(sample)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
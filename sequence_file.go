@@ -0,0 +1,226 @@
+package hadoop_streaming
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	SEQUENCE_FILE_VERSION       = 6
+	SEQUENCE_FILE_SYNC_SIZE     = 16
+	SEQUENCE_FILE_SYNC_ESCAPE   = -1
+	SEQUENCE_FILE_SYNC_INTERVAL = 100 * (4 + SEQUENCE_FILE_SYNC_SIZE)
+	TEXT_CLASS                  = "org.apache.hadoop.io.Text"
+	NULL_WRITABLE_CLASS         = "org.apache.hadoop.io.NullWritable"
+)
+
+func AppendVLong(buf []byte, i int64) []byte {
+	if i >= -112 && i <= 127 {
+		return append(buf, byte(i))
+	}
+	length := -112
+	if i < 0 {
+		i ^= -1
+		length = -120
+	}
+	for tmp := i; tmp != 0; tmp >>= 8 {
+		length--
+	}
+	buf = append(buf, byte(length))
+	if length < -120 {
+		length = -(length + 120)
+	} else {
+		length = -(length + 112)
+	}
+	for idx := length; idx != 0; idx-- {
+		buf = append(buf, byte(i>>((idx-1)*8)))
+	}
+	return buf
+}
+
+func ReadVLong(r io.ByteReader) (int64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	length := int(int8(first))
+	if length >= -112 {
+		return int64(length), nil
+	}
+	negative := length < -120
+	if negative {
+		length = -(length + 120)
+	} else {
+		length = -(length + 112)
+	}
+	var i int64
+	for idx := 0; idx < length; idx++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		i = i<<8 | int64(b)
+	}
+	if negative {
+		i ^= -1
+	}
+	return i, nil
+}
+
+func AppendText(buf []byte, text []byte) []byte {
+	buf = AppendVLong(buf, int64(len(text)))
+	return append(buf, text...)
+}
+
+type SequenceFileWriter struct {
+	writer   *bufio.Writer
+	sync     [SEQUENCE_FILE_SYNC_SIZE]byte
+	pos      int64
+	lastSync int64
+}
+
+func NewSequenceFileWriter(w io.Writer, keyClass, valueClass string) (*SequenceFileWriter, error) {
+	sw := &SequenceFileWriter{
+		writer: bufio.NewWriter(w),
+	}
+	if _, err := rand.Read(sw.sync[:]); err != nil {
+		return nil, err
+	}
+	header := []byte{'S', 'E', 'Q', SEQUENCE_FILE_VERSION}
+	header = AppendText(header, []byte(keyClass))
+	header = AppendText(header, []byte(valueClass))
+	header = append(header, 0, 0)
+	header = binary.BigEndian.AppendUint32(header, 0)
+	header = append(header, sw.sync[:]...)
+	if err := sw.write(header); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+func (sw *SequenceFileWriter) write(data []byte) error {
+	n, err := sw.writer.Write(data)
+	sw.pos += int64(n)
+	return err
+}
+
+func (sw *SequenceFileWriter) Append(key, value []byte) error {
+	var buf []byte
+	if sw.pos >= sw.lastSync+SEQUENCE_FILE_SYNC_INTERVAL {
+		buf = binary.BigEndian.AppendUint32(buf, 0xffffffff)
+		buf = append(buf, sw.sync[:]...)
+		sw.lastSync = sw.pos + int64(len(buf))
+	}
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(key)+len(value)))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(key)))
+	buf = append(buf, key...)
+	buf = append(buf, value...)
+	return sw.write(buf)
+}
+
+func (sw *SequenceFileWriter) Close() error {
+	return sw.writer.Flush()
+}
+
+type SequenceFileReader struct {
+	reader     *bufio.Reader
+	keyClass   string
+	valueClass string
+	sync       [SEQUENCE_FILE_SYNC_SIZE]byte
+}
+
+func NewSequenceFileReader(r io.Reader) (*SequenceFileReader, error) {
+	sr := &SequenceFileReader{
+		reader: bufio.NewReader(r),
+	}
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(sr.reader, magic); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic[:3], []byte("SEQ")) || magic[3] != SEQUENCE_FILE_VERSION {
+		return nil, fmt.Errorf("unsupported sequence file header: %q", magic)
+	}
+	keyClass, err := sr.readText()
+	if err != nil {
+		return nil, err
+	}
+	valueClass, err := sr.readText()
+	if err != nil {
+		return nil, err
+	}
+	sr.keyClass, sr.valueClass = string(keyClass), string(valueClass)
+	flags := make([]byte, 2)
+	if _, err := io.ReadFull(sr.reader, flags); err != nil {
+		return nil, err
+	}
+	if flags[0] != 0 || flags[1] != 0 {
+		return nil, fmt.Errorf("compressed sequence files are not supported")
+	}
+	var metadata uint32
+	if err := binary.Read(sr.reader, binary.BigEndian, &metadata); err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < metadata*2; i++ {
+		if _, err := sr.readText(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := io.ReadFull(sr.reader, sr.sync[:]); err != nil {
+		return nil, err
+	}
+	return sr, nil
+}
+
+func (sr *SequenceFileReader) readText() ([]byte, error) {
+	length, err := ReadVLong(sr.reader)
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("invalid text length: %v", length)
+	}
+	text := make([]byte, length)
+	_, err = io.ReadFull(sr.reader, text)
+	return text, err
+}
+
+func (sr *SequenceFileReader) KeyClass() string {
+	return sr.keyClass
+}
+
+func (sr *SequenceFileReader) ValueClass() string {
+	return sr.valueClass
+}
+
+func (sr *SequenceFileReader) Next() ([]byte, []byte, error) {
+	var recordLength int32
+	if err := binary.Read(sr.reader, binary.BigEndian, &recordLength); err != nil {
+		return nil, nil, err
+	}
+	if recordLength == SEQUENCE_FILE_SYNC_ESCAPE {
+		sync := make([]byte, SEQUENCE_FILE_SYNC_SIZE)
+		if _, err := io.ReadFull(sr.reader, sync); err != nil {
+			return nil, nil, err
+		}
+		if !bytes.Equal(sync, sr.sync[:]) {
+			return nil, nil, fmt.Errorf("sequence file sync check failed")
+		}
+		return sr.Next()
+	}
+	var keyLength int32
+	if err := binary.Read(sr.reader, binary.BigEndian, &keyLength); err != nil {
+		return nil, nil, err
+	}
+	if keyLength < 0 || keyLength > recordLength {
+		return nil, nil, fmt.Errorf("invalid sequence file record")
+	}
+	record := make([]byte, recordLength)
+	if _, err := io.ReadFull(sr.reader, record); err != nil {
+		return nil, nil, err
+	}
+	return record[:keyLength], record[keyLength:], nil
+}
@@ -91,10 +91,17 @@ type Serializer[T any] interface {
 	Serialize(value T) ([]byte, error)
 }
 
+type serializerProvider interface {
+	newSerializer() any
+}
+
 func NewSerializer[T any]() Serializer[T] {
 	var serializer interface{}
 	var to T
 	var noneKey NoneKey
+	if provider, ok := any(to).(serializerProvider); ok {
+		return provider.newSerializer().(Serializer[T])
+	}
 	toType := reflect.TypeOf(to)
 	toTypeKind := toType.Kind()
 	switch toTypeKind {
@@ -0,0 +1,175 @@
+package hadoop_streaming
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+type SpillingIterator[T any] struct {
+	source     Iterator[T]
+	serializer Serializer[T]
+	maxBytes   int64
+	tempDir    string
+	values     []T
+	memBytes   int64
+	file       *os.File
+	writer     *bufio.Writer
+	reader     *bufio.Reader
+	spilled    int
+	pos        int
+	exhausted  bool
+	next       *T
+	buf        []byte
+	err        error
+}
+
+func NewSpillingIterator[T any](source Iterator[T], serializer Serializer[T], maxBytes int64) *SpillingIterator[T] {
+	return &SpillingIterator[T]{
+		source:     source,
+		serializer: serializer,
+		maxBytes:   maxBytes,
+	}
+}
+
+func (iterator *SpillingIterator[T]) WithTempDir(dir string) *SpillingIterator[T] {
+	iterator.tempDir = dir
+	return iterator
+}
+
+func (iterator *SpillingIterator[T]) HasNext() bool {
+	if iterator.next != nil {
+		return true
+	}
+	if iterator.err != nil {
+		return false
+	}
+	if iterator.pos < len(iterator.values) {
+		iterator.next = &iterator.values[iterator.pos]
+		iterator.pos++
+		return true
+	}
+	if iterator.pos < len(iterator.values)+iterator.spilled {
+		value, err := iterator.readSpilled()
+		if err != nil {
+			iterator.err = err
+			return false
+		}
+		iterator.next = &value
+		iterator.pos++
+		return true
+	}
+	if iterator.exhausted || !iterator.source.HasNext() {
+		iterator.exhausted = true
+		return false
+	}
+	value := iterator.source.Next()
+	if err := iterator.buffer(value); err != nil {
+		iterator.err = err
+		return false
+	}
+	iterator.next = &value
+	iterator.pos++
+	return true
+}
+
+func (iterator *SpillingIterator[T]) Next() T {
+	value := *iterator.next
+	iterator.next = nil
+	return value
+}
+
+func (iterator *SpillingIterator[T]) buffer(value T) error {
+	data, err := iterator.serializer.Serialize(value)
+	if err != nil {
+		return err
+	}
+	size := int64(len(data))
+	if iterator.file == nil && iterator.memBytes+size <= iterator.maxBytes {
+		iterator.values = append(iterator.values, value)
+		iterator.memBytes += size
+		return nil
+	}
+	if iterator.file == nil {
+		if iterator.file, err = os.CreateTemp(iterator.tempDir, "reducer-values-*"); err != nil {
+			return err
+		}
+		iterator.writer = bufio.NewWriter(iterator.file)
+	}
+	iterator.buf = binary.AppendUvarint(iterator.buf[:0], uint64(len(data)))
+	if _, err := iterator.writer.Write(iterator.buf); err != nil {
+		return err
+	}
+	if _, err := iterator.writer.Write(data); err != nil {
+		return err
+	}
+	iterator.spilled++
+	return nil
+}
+
+func (iterator *SpillingIterator[T]) readSpilled() (T, error) {
+	var value T
+	size, err := binary.ReadUvarint(iterator.reader)
+	if err != nil {
+		return value, err
+	}
+	if uint64(cap(iterator.buf)) < size {
+		iterator.buf = make([]byte, size)
+	}
+	data := iterator.buf[:size]
+	if _, err := io.ReadFull(iterator.reader, data); err != nil {
+		return value, err
+	}
+	return iterator.serializer.Deserialize(data)
+}
+
+func (iterator *SpillingIterator[T]) Rewind() error {
+	iterator.next = nil
+	for iterator.err == nil && !iterator.exhausted {
+		iterator.pos = len(iterator.values) + iterator.spilled
+		if iterator.HasNext() {
+			iterator.Next()
+		}
+	}
+	if iterator.err != nil {
+		return iterator.err
+	}
+	iterator.pos = 0
+	if iterator.file == nil {
+		return nil
+	}
+	if err := iterator.writer.Flush(); err != nil {
+		iterator.err = err
+		return err
+	}
+	if _, err := iterator.file.Seek(0, io.SeekStart); err != nil {
+		iterator.err = err
+		return err
+	}
+	iterator.reader = bufio.NewReader(iterator.file)
+	return nil
+}
+
+func (iterator *SpillingIterator[T]) Len() int {
+	return len(iterator.values) + iterator.spilled
+}
+
+func (iterator *SpillingIterator[T]) Spilled() int {
+	return iterator.spilled
+}
+
+func (iterator *SpillingIterator[T]) Err() error {
+	return iterator.err
+}
+
+func (iterator *SpillingIterator[T]) Close() error {
+	iterator.values = nil
+	if iterator.file == nil {
+		return nil
+	}
+	name := iterator.file.Name()
+	err := iterator.file.Close()
+	iterator.file = nil
+	return MergeErrors(err, os.Remove(name))
+}
@@ -0,0 +1,198 @@
+package hadoop_streaming
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type sliceIterator[T any] struct {
+	values []T
+}
+
+func (iterator *sliceIterator[T]) HasNext() bool {
+	return len(iterator.values) != 0
+}
+
+func (iterator *sliceIterator[T]) Next() T {
+	value := iterator.values[0]
+	iterator.values = iterator.values[1:]
+	return value
+}
+
+func drain[T any](iterator Iterator[T]) []T {
+	var values []T
+	for iterator.HasNext() {
+		values = append(values, iterator.Next())
+	}
+	return values
+}
+
+func TestSpillingIterator(t *testing.T) {
+	values := []string{"a", "bb", "ccc", "", "dddd", "e\tf"}
+	tests := []struct {
+		maxBytes int64
+		spilled  int
+	}{
+		{100, 0},
+		{6, 2},
+		{0, 6},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		source := &sliceIterator[string]{values: append([]string(nil), values...)}
+		iterator := NewSpillingIterator[string](source, &StringSerializer{}, test.maxBytes).WithTempDir(dir)
+		for pass := 0; pass < 3; pass++ {
+			if got := drain[string](iterator); !reflect.DeepEqual(got, values) {
+				t.Fatalf("maxBytes %v pass %v: got %q, want %q", test.maxBytes, pass, got, values)
+			}
+			if err := iterator.Rewind(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if iterator.Len() != len(values) || iterator.Spilled() != test.spilled {
+			t.Errorf("maxBytes %v: Len = %v, Spilled = %v, want %v, %v",
+				test.maxBytes, iterator.Len(), iterator.Spilled(), len(values), test.spilled)
+		}
+		if err := iterator.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("maxBytes %v: temp files left: %v", test.maxBytes, entries)
+		}
+	}
+}
+
+func TestSpillingIteratorPartialPass(t *testing.T) {
+	values := []int{1, 22, 333, 4444, 55555}
+	source := &sliceIterator[int]{values: append([]int(nil), values...)}
+	iterator := NewSpillingIterator[int](source, &IntSerializer[int]{}, 3).WithTempDir(t.TempDir())
+	defer iterator.Close()
+	if !iterator.HasNext() || iterator.Next() != 1 || !iterator.HasNext() {
+		t.Fatal("expected values")
+	}
+	if err := iterator.Rewind(); err != nil {
+		t.Fatal(err)
+	}
+	if got := drain[int](iterator); !reflect.DeepEqual(got, values) {
+		t.Fatalf("after partial pass got %v, want %v", got, values)
+	}
+	if err := iterator.Rewind(); err != nil {
+		t.Fatal(err)
+	}
+	if !iterator.HasNext() || iterator.Next() != 1 || !iterator.HasNext() || iterator.Next() != 22 {
+		t.Fatal("expected values")
+	}
+	if !iterator.HasNext() || iterator.Next() != 333 {
+		t.Fatal("expected spilled value")
+	}
+	if err := iterator.Rewind(); err != nil {
+		t.Fatal(err)
+	}
+	if got := drain[int](iterator); !reflect.DeepEqual(got, values) {
+		t.Fatalf("after partial spilled pass got %v, want %v", got, values)
+	}
+	if iterator.Spilled() != 3 || iterator.Err() != nil {
+		t.Errorf("Spilled = %v, Err = %v", iterator.Spilled(), iterator.Err())
+	}
+}
+
+type reiterableReducer struct {
+	*DefaultReducer[string, int, string, int]
+}
+
+func (reducer *reiterableReducer) Reduce(key string, values Iterator[int],
+	ctx *ReducerContext[string, int, string, int]) error {
+	iterator := ctx.GetReiterableValues(1)
+	sum := 0
+	for _, value := range drain[int](iterator) {
+		sum += value
+	}
+	if err := iterator.Rewind(); err != nil {
+		return err
+	}
+	for _, value := range drain[int](iterator) {
+		if err := ctx.Write(key, value*100/sum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestGetReiterableValues(t *testing.T) {
+	var out strings.Builder
+	ctx := NewReducerContext[string, int, string, int](strings.NewReader("a\t1\na\t3\nb\t2\n"), &out)
+	ctx.GetCounters().WithReporter(&strings.Builder{})
+	if err := RunReducer[string, int, string, int](&reiterableReducer{}, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\t25\na\t75\nb\t100\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+// unluckySerializer cannot serialize 13, so spilling it fails.
+type unluckySerializer struct {
+	IntSerializer[int]
+}
+
+func (s *unluckySerializer) Serialize(from int) ([]byte, error) {
+	if from == 13 {
+		return nil, fmt.Errorf("unlucky value")
+	}
+	return s.IntSerializer.Serialize(from)
+}
+
+type spillingPartitionReducer struct {
+	*DefaultReducer[string, int, string, int]
+}
+
+func (reducer *spillingPartitionReducer) ReducePartition(groups *GroupIterator[string, int, string, int],
+	ctx *ReducerContext[string, int, string, int]) error {
+	for groups.HasNext() {
+		group := groups.Next()
+		sum := 0
+		for _, value := range drain[int](ctx.GetReiterableValues(0)) {
+			sum += value
+		}
+		if err := ctx.Write(group.Key, sum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestSpillErrorFailsGroup(t *testing.T) {
+	input := "a\t1\na\t13\na\t2\nb\t1\n"
+	newContext := func(out *strings.Builder) *ReducerContext[string, int, string, int] {
+		ctx := NewReducerContext[string, int, string, int](strings.NewReader(input), out)
+		ctx.WithValueInSerializer(&unluckySerializer{})
+		ctx.GetCounters().WithReporter(&strings.Builder{})
+		return ctx
+	}
+	var out strings.Builder
+	ctx := newContext(&out)
+	if err := RunReducer[string, int, string, int](&reiterableReducer{}, ctx); err == nil ||
+		!strings.Contains(err.Error(), "unlucky value") {
+		t.Errorf("RunReducer: err = %v, want the spill error", err)
+	}
+	ctx.Close()
+	if strings.HasPrefix(out.String(), "b") || strings.Contains(out.String(), "\nb") {
+		t.Errorf("RunReducer went on after the failed group: %q", out.String())
+	}
+	out.Reset()
+	ctx = newContext(&out)
+	if err := RunPartitionReducer[string, int, string, int](&spillingPartitionReducer{}, ctx); err == nil ||
+		!strings.Contains(err.Error(), "unlucky value") {
+		t.Errorf("RunPartitionReducer: err = %v, want the spill error", err)
+	}
+	ctx.Close()
+	if strings.Contains(out.String(), "b\t") {
+		t.Errorf("RunPartitionReducer went on after the failed group: %q", out.String())
+	}
+}
@@ -0,0 +1,169 @@
+package hadoop_streaming
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+func SelectSplitPoints(samples [][]byte, numPartitions int, comparator Comparator) ([][]byte, error) {
+	if numPartitions <= 0 {
+		return nil, fmt.Errorf("invalid number of partitions: %v", numPartitions)
+	}
+	if len(samples) < numPartitions-1 {
+		return nil, fmt.Errorf("too few samples for %v partitions: %v", numPartitions, len(samples))
+	}
+	if comparator == nil {
+		comparator = bytes.Compare
+	}
+	sorted := append([][]byte(nil), samples...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return comparator(sorted[i], sorted[j]) < 0
+	})
+	stepSize := float32(len(sorted)) / float32(numPartitions)
+	splits := make([][]byte, 0, numPartitions-1)
+	last := -1
+	for i := 1; i < numPartitions; i++ {
+		k := int(math.Round(float64(stepSize * float32(i))))
+		if k <= last {
+			k = last + 1
+		}
+		// Unlike InputSampler, skip every repeat of the last split point, since
+		// TotalOrderPartitioner rejects split points that are not increasing.
+		for last >= 0 && k < len(sorted) && comparator(sorted[last], sorted[k]) == 0 {
+			k++
+		}
+		if k >= len(sorted) {
+			return nil, fmt.Errorf("too few distinct samples for %v partitions", numPartitions)
+		}
+		splits = append(splits, sorted[k])
+		last = k
+	}
+	return splits, nil
+}
+
+func WritePartitionFile(w io.Writer, splits [][]byte) error {
+	sw, err := NewSequenceFileWriter(w, TEXT_CLASS, NULL_WRITABLE_CLASS)
+	if err != nil {
+		return err
+	}
+	for _, split := range splits {
+		if err := sw.Append(AppendText(nil, split), nil); err != nil {
+			return err
+		}
+	}
+	return sw.Close()
+}
+
+func BuildPartitionFile(samples io.Reader, w io.Writer, numPartitions int, comparator Comparator) error {
+	var keys [][]byte
+	var readErr error
+	ReadLines(samples, func(data []byte, err error) bool {
+		if err != nil && err != io.EOF {
+			readErr = err
+			return false
+		}
+		keys = append(keys, data)
+		return true
+	})
+	if readErr != nil {
+		return readErr
+	}
+	splits, err := SelectSplitPoints(keys, numPartitions, comparator)
+	if err != nil {
+		return err
+	}
+	return WritePartitionFile(w, splits)
+}
+
+func ReadPartitionFile(r io.Reader) ([][]byte, error) {
+	reader := bufio.NewReader(r)
+	if magic, err := reader.Peek(3); err == nil && bytes.Equal(magic, []byte("SEQ")) {
+		sr, err := NewSequenceFileReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		if sr.KeyClass() != TEXT_CLASS {
+			return nil, fmt.Errorf("unsupported partition key class: %v", sr.KeyClass())
+		}
+		var splits [][]byte
+		for {
+			key, _, err := sr.Next()
+			if err == io.EOF {
+				return splits, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			keyReader := bytes.NewReader(key)
+			length, err := ReadVLong(keyReader)
+			if err != nil || length != int64(keyReader.Len()) {
+				return nil, fmt.Errorf("invalid text key in partition file")
+			}
+			splits = append(splits, key[len(key)-int(length):])
+		}
+	}
+	var splits [][]byte
+	var readErr error
+	ReadLines(reader, func(data []byte, err error) bool {
+		if err != nil && err != io.EOF {
+			readErr = err
+			return false
+		}
+		splits = append(splits, data)
+		return true
+	})
+	return splits, readErr
+}
+
+type TotalOrderPartitioner struct {
+	splits     [][]byte
+	comparator Comparator
+}
+
+func NewTotalOrderPartitioner(splits [][]byte) *TotalOrderPartitioner {
+	return &TotalOrderPartitioner{
+		splits:     splits,
+		comparator: bytes.Compare,
+	}
+}
+
+func LoadTotalOrderPartitioner(path string) (*TotalOrderPartitioner, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	splits, err := ReadPartitionFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read partition file %v: %w", path, err)
+	}
+	return NewTotalOrderPartitioner(splits), nil
+}
+
+func (partitioner *TotalOrderPartitioner) WithComparator(comparator Comparator) *TotalOrderPartitioner {
+	partitioner.comparator = comparator
+	return partitioner
+}
+
+func (partitioner *TotalOrderPartitioner) NumPartitions() int {
+	return len(partitioner.splits) + 1
+}
+
+func (partitioner *TotalOrderPartitioner) Partition(key []byte, numPartitions int) int {
+	partition := sort.Search(len(partitioner.splits), func(i int) bool {
+		return partitioner.comparator(partitioner.splits[i], key) > 0
+	})
+	if partition >= numPartitions {
+		return numPartitions - 1
+	}
+	return partition
+}
+
+func PartitionPrefix(partition int) string {
+	return fmt.Sprintf("%05d", partition)
+}
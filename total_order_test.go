@@ -0,0 +1,180 @@
+package hadoop_streaming
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func byteStrings(values ...string) [][]byte {
+	data := make([][]byte, len(values))
+	for i, value := range values {
+		data[i] = []byte(value)
+	}
+	return data
+}
+
+func TestVLong(t *testing.T) {
+	tests := []struct {
+		value int64
+		data  []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{-112, []byte{0x90}},
+		{128, []byte{0x8f, 0x80}},
+		{256, []byte{0x8e, 0x01, 0x00}},
+		{-113, []byte{0x87, 0x70}},
+	}
+	for _, test := range tests {
+		data := AppendVLong(nil, test.value)
+		if !bytes.Equal(data, test.data) {
+			t.Errorf("AppendVLong(%v) = %x, want %x", test.value, data, test.data)
+		}
+		value, err := ReadVLong(bytes.NewReader(data))
+		if err != nil || value != test.value {
+			t.Errorf("ReadVLong(%x) = %v, %v, want %v", data, value, err, test.value)
+		}
+	}
+}
+
+func TestSelectSplitPoints(t *testing.T) {
+	tests := []struct {
+		samples       []string
+		numPartitions int
+		splits        []string
+	}{
+		{[]string{"9", "3", "0", "5", "1", "8", "2", "7", "4", "6"}, 4, []string{"3", "5", "8"}},
+		{[]string{"a", "a", "a", "a", "b", "c"}, 3, []string{"a", "b"}},
+		{[]string{"a", "a", "a", "a", "a", "b"}, 3, []string{"a", "b"}},
+		{[]string{"x"}, 1, []string{}},
+	}
+	for _, test := range tests {
+		splits, err := SelectSplitPoints(byteStrings(test.samples...), test.numPartitions, nil)
+		if err != nil {
+			t.Fatalf("SelectSplitPoints(%q, %v): %v", test.samples, test.numPartitions, err)
+		}
+		if !bytes.Equal(bytes.Join(splits, []byte{','}), []byte(strings.Join(test.splits, ","))) {
+			t.Errorf("SelectSplitPoints(%q, %v) = %q, want %q", test.samples, test.numPartitions, splits, test.splits)
+		}
+	}
+	if _, err := SelectSplitPoints(byteStrings("a", "a", "a", "a"), 3, nil); err == nil {
+		t.Errorf("expected an error for too few distinct samples")
+	}
+	if _, err := SelectSplitPoints(byteStrings("a"), 3, nil); err == nil {
+		t.Errorf("expected an error for too few samples")
+	}
+}
+
+func TestPartitionFileRoundTrip(t *testing.T) {
+	var splits [][]byte
+	for i := 0; i < 500; i++ {
+		splits = append(splits, []byte(fmt.Sprintf("key-%05d", i)))
+	}
+	var buf bytes.Buffer
+	if err := WritePartitionFile(&buf, splits); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	header := AppendText([]byte("SEQ\x06"), []byte(TEXT_CLASS))
+	header = AppendText(header, []byte(NULL_WRITABLE_CLASS))
+	header = append(header, 0, 0, 0, 0, 0, 0)
+	if !bytes.HasPrefix(data, header) {
+		t.Fatalf("unexpected header: %q", data[:len(header)])
+	}
+	sync := data[len(header) : len(header)+SEQUENCE_FILE_SYNC_SIZE]
+	if n := bytes.Count(data, append([]byte{0xff, 0xff, 0xff, 0xff}, sync...)); n == 0 {
+		t.Errorf("no sync markers written")
+	}
+	reader, err := NewSequenceFileReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.KeyClass() != TEXT_CLASS || reader.ValueClass() != NULL_WRITABLE_CLASS {
+		t.Errorf("classes = %v, %v", reader.KeyClass(), reader.ValueClass())
+	}
+	key, value, err := reader.Next()
+	if err != nil || !bytes.Equal(key, AppendText(nil, splits[0])) || len(value) != 0 {
+		t.Errorf("Next() = %q, %q, %v", key, value, err)
+	}
+	read, err := ReadPartitionFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(splits) {
+		t.Fatalf("read %v splits, want %v", len(read), len(splits))
+	}
+	for i := range splits {
+		if !bytes.Equal(read[i], splits[i]) {
+			t.Fatalf("split %v = %q, want %q", i, read[i], splits[i])
+		}
+	}
+}
+
+func TestBuildPartitionFile(t *testing.T) {
+	var buf bytes.Buffer
+	if err := BuildPartitionFile(strings.NewReader("d\nb\na\nc\n"), &buf, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	splits, err := ReadPartitionFile(&buf)
+	if err != nil || len(splits) != 1 || string(splits[0]) != "c" {
+		t.Errorf("splits = %q, %v", splits, err)
+	}
+	splits, err = ReadPartitionFile(strings.NewReader("b\nd\n"))
+	if err != nil || len(splits) != 2 || string(splits[1]) != "d" {
+		t.Errorf("text splits = %q, %v", splits, err)
+	}
+}
+
+func TestTotalOrderPartitioner(t *testing.T) {
+	partitioner := NewTotalOrderPartitioner(byteStrings("b", "d"))
+	tests := []struct {
+		key       string
+		partition int
+	}{
+		{"", 0},
+		{"a", 0},
+		{"b", 1},
+		{"c", 1},
+		{"d", 2},
+		{"e", 2},
+	}
+	for _, test := range tests {
+		if partition := partitioner.Partition([]byte(test.key), 3); partition != test.partition {
+			t.Errorf("Partition(%q) = %v, want %v", test.key, partition, test.partition)
+		}
+	}
+	comparator, err := NewKeyFieldComparator("-k1,1n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	numeric := NewTotalOrderPartitioner(byteStrings("10", "100")).WithComparator(comparator.Compare)
+	if partition := numeric.Partition([]byte("9"), 3); partition != 0 {
+		t.Errorf("numeric Partition(9) = %v, want 0", partition)
+	}
+	if partition := numeric.Partition([]byte("50"), 3); partition != 1 {
+		t.Errorf("numeric Partition(50) = %v, want 1", partition)
+	}
+}
+
+func TestSamplers(t *testing.T) {
+	interval := NewIntervalSampler(0.25)
+	reservoir := NewReservoirSampler(10).WithSeed(1)
+	random := NewRandomSampler(0.5, 10).WithSeed(1)
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprint(i))
+		interval.Sample(key)
+		reservoir.Sample(key)
+		random.Sample(key)
+	}
+	if n := len(interval.Samples()); n != 250 {
+		t.Errorf("interval samples = %v, want 250", n)
+	}
+	if n := len(reservoir.Samples()); n != 10 {
+		t.Errorf("reservoir samples = %v, want 10", n)
+	}
+	if n := len(random.Samples()); n != 10 {
+		t.Errorf("random samples = %v, want 10", n)
+	}
+}
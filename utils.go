@@ -2,7 +2,9 @@ package hadoop_streaming
 
 import (
 	"bufio"
+	"fmt"
 	"io"
+	"runtime/debug"
 	"strings"
 	"unsafe"
 )
@@ -58,6 +60,10 @@ func (me *MultiError) Errs() []error {
 	return me.errs
 }
 
+func (me *MultiError) Unwrap() []error {
+	return me.errs
+}
+
 func (me *MultiError) AutoConvert() error {
 	errs := me.errs
 	if len(errs) == 0 {
@@ -87,3 +93,23 @@ func MergeErrors(errs ...error) error {
 	err.AddErrs(errs...)
 	return err.AutoConvert()
 }
+
+type PanicError struct {
+	Value any
+	Stack []byte
+	Key   any
+	Raw   []byte
+}
+
+func NewPanicError(value any, key any, raw []byte) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: debug.Stack(),
+		Key:   key,
+		Raw:   raw,
+	}
+}
+
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v, key=%v, raw=%q\n%s", pe.Value, pe.Key, pe.Raw, pe.Stack)
+}
@@ -0,0 +1,68 @@
+package hadoop_streaming
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type SerializeError struct {
+	Err error
+}
+
+func (se *SerializeError) Error() string {
+	return fmt.Sprintf("serialize error: %v", se.Err)
+}
+
+func (se *SerializeError) Unwrap() error {
+	return se.Err
+}
+
+func IsSerializeError(err error) bool {
+	var se *SerializeError
+	return errors.As(err, &se)
+}
+
+type WriteErrorHandler[KEYOUT, VALUEOUT any] func(err error, key KEYOUT, value VALUEOUT) error
+
+func SkipWriteError[KEYOUT, VALUEOUT any](counter Counter) WriteErrorHandler[KEYOUT, VALUEOUT] {
+	return func(err error, key KEYOUT, value VALUEOUT) error {
+		if !IsSerializeError(err) {
+			return err
+		}
+		if counter != nil {
+			counter.Increment(1)
+		}
+		return nil
+	}
+}
+
+func divertedRecord[KEYOUT, VALUEOUT any](err error, key KEYOUT, value VALUEOUT) (string, string) {
+	return strconv.Quote(fmt.Sprint(key)), strconv.Quote(fmt.Sprint(value)) + "\t" + strconv.Quote(err.Error())
+}
+
+func DivertWriteError[KEYOUT, VALUEOUT any](w io.Writer) WriteErrorHandler[KEYOUT, VALUEOUT] {
+	return func(err error, key KEYOUT, value VALUEOUT) error {
+		if !IsSerializeError(err) {
+			return err
+		}
+		keyField, valueField := divertedRecord(err, key, value)
+		if _, err2 := fmt.Fprintf(w, "%v\t%v\n", keyField, valueField); err2 != nil {
+			return MergeErrors(err, err2)
+		}
+		return nil
+	}
+}
+
+func DivertWriteErrorTo[KEYOUT, VALUEOUT any](out *NamedOutput[string, string]) WriteErrorHandler[KEYOUT, VALUEOUT] {
+	return func(err error, key KEYOUT, value VALUEOUT) error {
+		if !IsSerializeError(err) {
+			return err
+		}
+		if err2 := out.Write(divertedRecord(err, key, value)); err2 != nil {
+			return MergeErrors(err, err2)
+		}
+		return nil
+	}
+}